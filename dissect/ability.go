@@ -0,0 +1,52 @@
+package dissect
+
+import "github.com/rs/zerolog/log"
+
+// AbilityUpdate is a charge of an operator ability used during the round.
+// Charges are inferred from the ammo of the ability launcher, so which
+// ability was used comes from the Operator. Secondary gadgets, throwables
+// and destroyed gadgets are not decoded.
+type AbilityUpdate struct {
+	Username      string          `json:"username,omitempty"` // owner of the ability
+	Operator      Operator        `json:"operator,omitempty"`
+	EntityID      uint32          `json:"entityID,omitempty"` // ability launcher
+	Position      *PlayerPosition `json:"position,omitempty"` // where the player was, if known
+	Time          string          `json:"time"`
+	TimeInSeconds float64         `json:"timeInSeconds"`
+}
+
+// addAbility records an ability update and emits an AbilityEvent.
+func (r *Reader) addAbility(u AbilityUpdate) error {
+	r.AbilityUpdates = append(r.AbilityUpdates, u)
+	log.Debug().Interface("ability_update", u).Send()
+	return r.emit(AbilityEvent{u})
+}
+
+// deployAbility records charges of the ability launcher entity used by the player.
+func (r *Reader) deployAbility(playerIdx int, entityID uint32, charges int) error {
+	p := r.Header.Players[playerIdx]
+	for range charges {
+		u := AbilityUpdate{
+			Username:      p.Username,
+			Operator:      p.Operator,
+			EntityID:      entityID,
+			Position:      r.lastPosition(playerIdx),
+			Time:          r.timeRaw,
+			TimeInSeconds: r.time,
+		}
+		if err := r.addAbility(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastPosition returns the last position read for the player, or nil.
+// Positions are only read with movement tracking enabled.
+func (r *Reader) lastPosition(playerIdx int) *PlayerPosition {
+	pos, ok := r.lastPositions[playerIdx]
+	if !ok {
+		return nil
+	}
+	return &pos
+}
@@ -0,0 +1,345 @@
+package dissect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var zstdFrameMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+
+var errInvalidFrame = errors.New("dissect: invalid zstd frame")
+
+// Anonymizer rewrites replays with player usernames, profile ids and player ids
+// replaced by pseudonyms. Pseudonyms have the length of the value they
+// replace, so packets keep their layout. The same Anonymizer keeps
+// pseudonyms consistent across replays, e.g. the rounds of a match.
+type Anonymizer struct {
+	pseudonyms map[string]string
+	assigned   map[string]bool // pseudonyms in use
+	playerIDs  map[string]bool // original values that are numeric player ids
+	names      int
+	profiles   int
+	ids        int
+}
+
+// anonymizedValue is the kind of value an Anonymizer replaces.
+type anonymizedValue int
+
+const (
+	usernameValue anonymizedValue = iota
+	profileIDValue
+	playerIDValue
+)
+
+func NewAnonymizer() *Anonymizer {
+	return &Anonymizer{
+		pseudonyms: make(map[string]string),
+		assigned:   make(map[string]bool),
+		playerIDs:  make(map[string]bool),
+	}
+}
+
+// Anonymize copies the replay in to out with a new Anonymizer.
+func Anonymize(in io.Reader, out io.Writer) error {
+	return NewAnonymizer().Anonymize(in, out)
+}
+
+// Pseudonyms returns the pseudonym of every username, profile id and player id replaced so far.
+func (a *Anonymizer) Pseudonyms() map[string]string {
+	return maps.Clone(a.pseudonyms)
+}
+
+// Anonymize copies the replay in to out with player usernames, profile ids and
+// player ids replaced in the header and body, including the usernames inside
+// match feedback messages. The replay is re-compressed with the chunked or
+// non-chunked layout of the original.
+func (a *Anonymizer) Anonymize(in io.Reader, out io.Writer) error {
+	raw, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	r, err := NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	if err = r.Read(); !Ok(err) {
+		return err
+	}
+	for _, p := range r.Header.Players {
+		a.add(p.Username, usernameValue)
+	}
+	a.add(r.Header.RecordingProfileID, profileIDValue)
+	for _, p := range r.Header.Players {
+		a.add(p.ProfileID, profileIDValue)
+	}
+	if r.Header.RecordingPlayerID != 0 {
+		a.add(strconv.FormatUint(r.Header.RecordingPlayerID, 10), playerIDValue)
+	}
+	for _, p := range r.Header.Players {
+		if p.ID != 0 {
+			a.add(strconv.FormatUint(p.ID, 10), playerIDValue)
+		}
+	}
+	return a.rewrite(raw, out, r.messageSpans, r.playerIDOffsets)
+}
+
+// add assigns a pseudonym to value if it has none yet.
+func (a *Anonymizer) add(value string, kind anonymizedValue) {
+	if _, ok := a.pseudonyms[value]; ok || value == "" || a.assigned[value] {
+		return
+	}
+	var s string
+	switch kind {
+	case profileIDValue:
+		a.profiles++
+		if len(value) == 36 { // uuid
+			s = fmt.Sprintf("00000000-0000-4000-8000-%012d", a.profiles)
+		} else {
+			s = pseudonym("Profile", a.profiles, len(value))
+		}
+	case playerIDValue:
+		for tries := 0; (s == "" || s == value || a.assigned[s]) && tries < 100; tries++ {
+			a.ids++
+			s = pseudonym("1", a.ids, len(value))
+		}
+		a.playerIDs[value] = true
+	default:
+		a.names++
+		s = pseudonym("Player", a.names, len(value))
+	}
+	a.pseudonyms[value] = s
+	a.assigned[s] = true
+}
+
+// pseudonym returns prefix and n zero padded to size bytes,
+// shortening the prefix when needed.
+func pseudonym(prefix string, n int, size int) string {
+	digits := fmt.Sprint(n)
+	for len(prefix)+len(digits) > size && len(prefix) > 0 {
+		prefix = prefix[:len(prefix)-1]
+	}
+	s := prefix + strings.Repeat("0", max(size-len(prefix)-len(digits), 0)) + digits
+	return s[:min(len(s), size)]
+}
+
+// values returns the original values, longest first.
+func (a *Anonymizer) values() []string {
+	values := make([]string, 0, len(a.pseudonyms))
+	for v := range a.pseudonyms {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+// replaceFields replaces the original values of the string fields in b with
+// their pseudonyms in place. Only values right after their length, as read
+// by Reader.String, or after their length and strSep, as read from the header,
+// are replaced so matching bytes of other packet fields are left untouched.
+// Player ids are only replaced as the values of the header player id keys.
+func (a *Anonymizer) replaceFields(b []byte, values []string) {
+	for _, v := range values {
+		size := byte(len(v))
+		playerID := a.playerIDs[v]
+		for pos := 0; ; {
+			i := bytes.Index(b[pos:], []byte(v))
+			if i < 0 {
+				break
+			}
+			i += pos
+			pos = i + 1
+			body := i >= 1 && b[i-1] == size && !playerID
+			header := i >= 8 && b[i-8] == size && bytes.Equal(b[i-7:i], strSep)
+			if playerID {
+				header = header && bytes.HasSuffix(b[:i-8], []byte("playerid"))
+			}
+			if body || header {
+				copy(b[i:], a.pseudonyms[v])
+				pos = i + len(v)
+			}
+		}
+	}
+}
+
+// replaceMessage replaces the usernames anywhere in a match feedback message.
+// Messages have a single length for the whole text, e.g. "<username> left the game",
+// so only whole words are replaced.
+func (a *Anonymizer) replaceMessage(msg []byte, values []string) {
+	for _, v := range values {
+		if a.playerIDs[v] {
+			continue
+		}
+		for pos := 0; ; {
+			i := bytes.Index(msg[pos:], []byte(v))
+			if i < 0 {
+				break
+			}
+			i += pos
+			pos = i + 1
+			before, _ := utf8.DecodeLastRune(msg[:i])
+			after, _ := utf8.DecodeRune(msg[i+len(v):])
+			if isWordRune(before) || isWordRune(after) {
+				continue
+			}
+			copy(msg[i:], a.pseudonyms[v])
+			pos = i + len(v)
+		}
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// replacePlayerID replaces the little endian player id at the start of b.
+func (a *Anonymizer) replacePlayerID(b []byte) {
+	if len(b) < 8 {
+		return
+	}
+	v := strconv.FormatUint(binary.LittleEndian.Uint64(b), 10)
+	if !a.playerIDs[v] {
+		return
+	}
+	id, err := strconv.ParseUint(a.pseudonyms[v], 10, 64)
+	if err != nil {
+		return
+	}
+	binary.LittleEndian.PutUint64(b, id)
+}
+
+// rewrite writes raw with the pseudonyms applied. Uncompressed data (the chunked
+// header and the data between sections) is rewritten in place. The zstd frames are
+// decompressed together, rewritten and compressed again with their original sizes.
+// messages and playerIDs locate the match feedback messages and the player ids
+// of player packets in the decompressed data, as found by Reader.Read.
+func (a *Anonymizer) rewrite(raw []byte, out io.Writer, messages [][2]int, playerIDs []int) error {
+	values := a.values()
+	type segment struct {
+		raw  []byte // uncompressed data
+		size int    // decompressed size of a frame when raw is nil
+	}
+	segments := make([]segment, 0)
+	body := make([]byte, 0, len(raw))
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return err
+	}
+	defer decoder.Close()
+	start := 0
+	for pos := 0; pos < len(raw); {
+		i := bytes.Index(raw[pos:], zstdFrameMagic)
+		if i < 0 {
+			break
+		}
+		pos += i
+		n, err := zstdFrameSize(raw[pos:])
+		if err != nil {
+			pos++
+			continue
+		}
+		decompressed, err := decoder.DecodeAll(raw[pos:pos+n], nil)
+		if err != nil {
+			pos++
+			continue
+		}
+		if start < pos {
+			segments = append(segments, segment{raw: raw[start:pos]})
+		}
+		segments = append(segments, segment{size: len(decompressed)})
+		body = append(body, decompressed...)
+		pos += n
+		start = pos
+	}
+	if start < len(raw) {
+		segments = append(segments, segment{raw: raw[start:]})
+	}
+	// pseudonyms keep the length of the original values, so frame sizes are unchanged
+	a.replaceFields(body, values)
+	for _, m := range messages {
+		if m[0] >= 0 && m[0]+m[1] <= len(body) {
+			a.replaceMessage(body[m[0]:m[0]+m[1]], values)
+		}
+	}
+	for _, offset := range playerIDs {
+		if offset >= 0 && offset+8 <= len(body) {
+			a.replacePlayerID(body[offset : offset+8])
+		}
+	}
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return err
+	}
+	defer encoder.Close()
+	for _, s := range segments {
+		var b []byte
+		if s.raw != nil {
+			b = bytes.Clone(s.raw)
+			a.replaceFields(b, values)
+		} else {
+			b = encoder.EncodeAll(body[:s.size], nil)
+			body = body[s.size:]
+		}
+		if _, err = out.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zstdFrameSize returns the compressed size of the zstd frame at the start of b.
+func zstdFrameSize(b []byte) (int, error) {
+	if len(b) < 5 || !bytes.Equal(b[:4], zstdFrameMagic) {
+		return 0, errInvalidFrame
+	}
+	descriptor := b[4]
+	singleSegment := descriptor&0x20 != 0
+	n := 5
+	if !singleSegment {
+		n++ // window descriptor
+	}
+	n += [4]int{0, 1, 2, 4}[descriptor&0x03] // dictionary id
+	contentSize := [4]int{0, 2, 4, 8}[descriptor>>6]
+	if contentSize == 0 && singleSegment {
+		contentSize = 1
+	}
+	n += contentSize
+	for last := false; !last; {
+		if n+3 > len(b) {
+			return 0, errInvalidFrame
+		}
+		header := int(b[n]) | int(b[n+1])<<8 | int(b[n+2])<<16
+		n += 3
+		last = header&1 != 0
+		size := header >> 3
+		switch (header >> 1) & 0x03 {
+		case 1: // RLE
+			size = 1
+		case 3: // reserved
+			return 0, errInvalidFrame
+		}
+		n += size
+	}
+	if descriptor&0x04 != 0 {
+		n += 4 // checksum
+	}
+	if n > len(b) {
+		return 0, errInvalidFrame
+	}
+	return n, nil
+}
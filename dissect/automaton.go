@@ -0,0 +1,91 @@
+package dissect
+
+// automaton is an Aho-Corasick matcher compiled from the Listen patterns.
+// Failure links are folded into a dense transition table, so scanning
+// costs a single lookup per byte regardless of the number of patterns.
+type automaton struct {
+	next   [][256]int32
+	out    [][]int // query indexes ending at each state, in ascending order
+	maxLen int
+}
+
+func newAutomaton(queries [][]byte) *automaton {
+	a := &automaton{
+		next: make([][256]int32, 1),
+		out:  make([][]int, 1),
+	}
+	// Build the trie. Transition 0 doubles as "missing" since the root is never a child.
+	for i, query := range queries {
+		if len(query) > a.maxLen {
+			a.maxLen = len(query)
+		}
+		if len(query) == 0 {
+			continue
+		}
+		state := int32(0)
+		for _, b := range query {
+			if a.next[state][b] == 0 {
+				a.next = append(a.next, [256]int32{})
+				a.out = append(a.out, nil)
+				a.next[state][b] = int32(len(a.next) - 1)
+			}
+			state = a.next[state][b]
+		}
+		a.out[state] = append(a.out[state], i)
+	}
+	// Breadth-first pass computing failure links and completing the table.
+	fail := make([]int32, len(a.next))
+	queue := make([]int32, 0, len(a.next))
+	for b := 0; b < 256; b++ {
+		if child := a.next[0][b]; child != 0 {
+			queue = append(queue, child)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		if f := fail[state]; len(a.out[f]) > 0 {
+			a.out[state] = mergeSorted(a.out[state], a.out[f])
+		}
+		for b := 0; b < 256; b++ {
+			child := a.next[state][b]
+			if child == 0 {
+				a.next[state][b] = a.next[fail[state]][b]
+				continue
+			}
+			fail[child] = a.next[fail[state]][b]
+			queue = append(queue, child)
+		}
+	}
+	return a
+}
+
+// scan feeds b through the automaton starting at state and appends every match
+// ending at or after minEnd. Match offsets are the index of the last pattern byte
+// plus base. It returns the state after the last byte so scanning can resume.
+func (a *automaton) scan(b []byte, state int32, base int, minEnd int, matches []match) (int32, []match) {
+	for i, c := range b {
+		state = a.next[state][c]
+		if out := a.out[state]; len(out) > 0 && base+i >= minEnd {
+			for _, q := range out {
+				matches = append(matches, match{base + i, q})
+			}
+		}
+	}
+	return state, matches
+}
+
+func mergeSorted(a, b []int) []int {
+	merged := make([]int, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0] <= b[0] {
+			merged = append(merged, a[0])
+			a = a[1:]
+		} else {
+			merged = append(merged, b[0])
+			b = b[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
@@ -0,0 +1,197 @@
+package dissect
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// naiveScan is the scanner Read used before the automaton: five fixed blocks
+// with a per-query prefix index. Kept as a benchmark baseline.
+func naiveScan(b []byte, queries [][]byte, start int, end int) []match {
+	numWorkers := 5
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	matches := make([]match, 0)
+	blockSize := (end - start) / numWorkers
+	wg.Add(numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		blockStart := start + (w * blockSize)
+		blockEnd := blockStart + blockSize
+		if w > 0 {
+			blockStart += 1
+		}
+		if w == numWorkers-1 {
+			blockEnd = end - 1
+		}
+		go func() {
+			defer wg.Done()
+			indexes := make([]int, len(queries))
+			found := make([]match, 0)
+			for i := blockStart; i <= blockEnd; i++ {
+				for j, query := range queries {
+					if b[i] == query[indexes[j]] {
+						indexes[j]++
+						if indexes[j] == len(query) {
+							indexes[j] = 0
+							found = append(found, match{i, j})
+						}
+					} else {
+						indexes[j] = 0
+					}
+				}
+			}
+			mu.Lock()
+			matches = append(matches, found...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].offset < matches[j].offset
+	})
+	return matches
+}
+
+// bruteScan checks every query at every offset.
+func bruteScan(b []byte, queries [][]byte) []match {
+	matches := make([]match, 0)
+	for i := range b {
+		for j, query := range queries {
+			if i+1 >= len(query) && bytes.Equal(b[i+1-len(query):i+1], query) {
+				matches = append(matches, match{i, j})
+			}
+		}
+	}
+	return matches
+}
+
+func testReader(b []byte, queries [][]byte) *Reader {
+	r := &Reader{b: b}
+	for _, q := range queries {
+		r.Listen(q, func(*Reader) error { return nil })
+	}
+	return r
+}
+
+func TestAutomaton_Overlaps(t *testing.T) {
+	queries := [][]byte{{0xAA, 0xAA, 0xBB}, {0xAA, 0xBB}, {0xBB, 0xAA, 0xAA}}
+	b := []byte{0xAA, 0xAA, 0xAA, 0xBB, 0xAA, 0xAA, 0xBB}
+	r := testReader(b, queries)
+	got, _ := r.scan(context.Background(), newAutomaton(r.queries), 0, len(b))
+	want := bruteScan(b, queries)
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("match %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAutomaton_Cancel(t *testing.T) {
+	b := make([]byte, 2*scanChunkSize)
+	r := testReader(b, [][]byte{{0x01}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.scan(ctx, newAutomaton(r.queries), 0, len(b)); err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestAutomaton_BlockBoundaries(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	queries := [][]byte{{0x01, 0x02, 0x03, 0x04}, {0x03, 0x04, 0x05}, {0x04}}
+	rng := rand.New(rand.NewSource(1))
+	b := make([]byte, 8*minBlockSize+17)
+	for i := range b {
+		b[i] = byte(rng.Intn(6))
+	}
+	// plant a pattern across every possible block boundary
+	for i := 1; i < 8; i++ {
+		copy(b[i*minBlockSize-2:], queries[0])
+	}
+	r := testReader(b, queries)
+	got, _ := r.scan(context.Background(), newAutomaton(r.queries), 0, len(b))
+	want := bruteScan(b, queries)
+	if len(got) != len(want) {
+		t.Fatalf("got %d matches, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("match %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// benchmarkData returns decompressed replays from the test corpus,
+// falling back to random data when no replays are available.
+func benchmarkData(b *testing.B) [][]byte {
+	b.Helper()
+	data := make([][]byte, 0)
+	filepath.WalkDir("test/data/replays/valid", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".rec") {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r, err := NewReader(f)
+		if err != nil {
+			b.Fatalf(`could not read "%s": %v`, path, err)
+		}
+		data = append(data, r.b)
+		return nil
+	})
+	if len(data) == 0 {
+		rng := rand.New(rand.NewSource(1))
+		random := make([]byte, 16*1024*1024)
+		rng.Read(random)
+		data = append(data, random)
+	}
+	return data
+}
+
+func benchmarkQueries() [][]byte {
+	r := &Reader{}
+	r.Header.CodeVersion = Y10S4
+	r.listenDefaults()
+	return r.queries
+}
+
+func BenchmarkScan_Naive(b *testing.B) {
+	data := benchmarkData(b)
+	queries := benchmarkQueries()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, d := range data {
+			b.SetBytes(int64(len(d)))
+			naiveScan(d, queries, 0, len(d))
+		}
+	}
+}
+
+func BenchmarkScan_Automaton(b *testing.B) {
+	data := benchmarkData(b)
+	queries := benchmarkQueries()
+	a := newAutomaton(queries)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, d := range data {
+			b.SetBytes(int64(len(d)))
+			r := &Reader{b: d, queries: queries}
+			r.scan(context.Background(), a, 0, len(d))
+		}
+	}
+}
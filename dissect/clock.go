@@ -0,0 +1,176 @@
+package dissect
+
+import (
+	"math"
+	"sort"
+)
+
+// clockTick is a change of the round clock read by readTime or readY7Time.
+type clockTick struct {
+	packetNum int     // position packets counted before the tick
+	time      float64 // round clock in seconds
+	elapsed   float64 // seconds since the first tick
+}
+
+// maxTickDrop is the most seconds the clock may count down between two ticks.
+// Larger drops, like the clock switching to the defuser timer, start a new phase.
+const maxTickDrop = 2
+
+// phaseChange reports whether the clock went from cur to next by starting a new
+// phase, such as prep to action, rather than by counting down.
+func phaseChange(cur, next float64) bool {
+	return next > cur || cur-next > maxTickDrop
+}
+
+// recordTick remembers a change of the round clock so positions can be
+// stamped with it. The clock counting down adds to the elapsed time,
+// while a new phase adds nothing.
+func (r *Reader) recordTick(seconds float64) {
+	if len(r.clockTicks) > 0 && !phaseChange(r.time, seconds) {
+		r.elapsed += r.time - seconds
+	}
+	r.clockTicks = append(r.clockTicks, clockTick{
+		packetNum: r.movementCounter,
+		time:      seconds,
+		elapsed:   r.elapsed,
+	})
+}
+
+// packetClock converts position packet numbers to the round clock and the
+// elapsed time, interpolating between the clock ticks around each packet.
+// Replays without clock ticks fall back to spreading the packets evenly
+// over the prep and action phases.
+type packetClock struct {
+	ticks     []clockTick
+	minPkt    int
+	pktRange  float64
+	totalTime float64
+}
+
+func (r *Reader) newPacketClock() packetClock {
+	var minPkt, maxPkt int = -1, -1
+	for _, pos := range r.rawPositions {
+		if minPkt < 0 || pos.packetNum < minPkt {
+			minPkt = pos.packetNum
+		}
+		if pos.packetNum > maxPkt {
+			maxPkt = pos.packetNum
+		}
+	}
+	pktRange := float64(maxPkt - minPkt)
+	if pktRange <= 0 {
+		pktRange = 1
+	}
+
+	var minCountdown float64 = 180
+	for _, event := range r.MatchFeedback {
+		if event.TimeInSeconds > 0 && event.TimeInSeconds < minCountdown {
+			minCountdown = event.TimeInSeconds
+		}
+	}
+	actionDuration := 180.0 - minCountdown
+	if actionDuration < 10 || math.IsNaN(actionDuration) || math.IsInf(actionDuration, 0) {
+		actionDuration = 180.0
+	}
+	totalTime := 45.0 + actionDuration
+	if totalTime <= 0 || math.IsNaN(totalTime) || math.IsInf(totalTime, 0) {
+		totalTime = 225.0
+	}
+	return packetClock{ticks: r.clockTicks, minPkt: minPkt, pktRange: pktRange, totalTime: totalTime}
+}
+
+// segment returns the last tick before the packet, the tick after it and
+// how far the packet is between them (0-1). ok is false without ticks.
+func (c packetClock) segment(pkt int) (cur, next clockTick, frac float64, ok bool) {
+	if len(c.ticks) == 0 {
+		return clockTick{}, clockTick{}, 0, false
+	}
+	i := sort.Search(len(c.ticks), func(i int) bool {
+		return c.ticks[i].packetNum >= pkt
+	}) - 1
+	if i < 0 {
+		return c.ticks[0], c.ticks[0], 0, true
+	}
+	if i == len(c.ticks)-1 {
+		return c.ticks[i], c.ticks[i], 0, true
+	}
+	cur, next = c.ticks[i], c.ticks[i+1]
+	// the first packet after a tick is read at the tick
+	frac = float64(pkt-cur.packetNum-1) / float64(next.packetNum-cur.packetNum)
+	return cur, next, frac, true
+}
+
+// roundTime returns the round clock when the packet was read.
+func (c packetClock) roundTime(pkt int) float64 {
+	cur, next, frac, ok := c.segment(pkt)
+	if !ok {
+		return 0
+	}
+	if next.time == cur.time || phaseChange(cur.time, next.time) {
+		return cur.time
+	}
+	return cur.time - frac*(cur.time-next.time)
+}
+
+// elapsed returns the seconds elapsed since the first clock tick when the packet was read.
+func (c packetClock) elapsed(pkt int) float64 {
+	cur, next, frac, ok := c.segment(pkt)
+	if !ok {
+		t := (float64(pkt-c.minPkt) / c.pktRange) * c.totalTime
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return 0
+		}
+		return t
+	}
+	return cur.elapsed + frac*(next.elapsed-cur.elapsed)
+}
+
+// actionStart returns the packet number at which the action phase starts,
+// found where the clock jumps up after the prep phase.
+func (c packetClock) actionStart() int {
+	for i := 1; i < len(c.ticks); i++ {
+		if c.ticks[i].time > c.ticks[i-1].time {
+			return c.ticks[i].packetNum
+		}
+	}
+	return c.minPkt + int(c.pktRange*45.0/c.totalTime)
+}
+
+// clockAt returns the round clock the elapsed seconds after the first tick.
+// At a phase change, the new phase is preferred.
+func (c packetClock) clockAt(elapsed float64) float64 {
+	if len(c.ticks) == 0 {
+		return 0
+	}
+	i := sort.Search(len(c.ticks), func(i int) bool {
+		return c.ticks[i].elapsed > elapsed
+	}) - 1
+	if i < 0 {
+		return c.ticks[0].time
+	}
+	t := c.ticks[i].time - (elapsed - c.ticks[i].elapsed)
+	if i+1 < len(c.ticks) && t < c.ticks[i+1].time {
+		return c.ticks[i+1].time
+	}
+	return max(t, 0)
+}
+
+// elapsedAt returns the elapsed seconds when the round clock showed the time,
+// such as the TimeInSeconds of a MatchUpdate. The clock shows the same time in
+// the prep and action phases, so the latest phase is preferred.
+func (c packetClock) elapsedAt(time float64) (float64, bool) {
+	elapsed, found := 0.0, false
+	for i, cur := range c.ticks {
+		if i+1 == len(c.ticks) {
+			if cur.time == time {
+				elapsed, found = cur.elapsed, true
+			}
+			break
+		}
+		next := c.ticks[i+1]
+		if next.time < cur.time && !phaseChange(cur.time, next.time) && cur.time >= time && time >= next.time {
+			elapsed, found = cur.elapsed+(cur.time-time), true
+		}
+	}
+	return elapsed, found
+}
@@ -0,0 +1,89 @@
+package dissect
+
+import (
+	"sort"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Seconds of the round clock used to tell revives from duplicate packets.
+const (
+	bleedOutWindow  = 30 // longest a player stays downed before bleeding out
+	duplicateWindow = 1  // DBNOs of a player by the same attacker this close are one DBNO
+)
+
+// DownRef links a Kill, Revive or BleedOut to the DBNO of its player.
+type DownRef struct {
+	Index         int     `json:"index"`    // index of the DBNO in MatchFeedback
+	Username      string  `json:"username"` // player who downed the target
+	Time          string  `json:"time"`
+	TimeInSeconds float64 `json:"timeInSeconds"`
+}
+
+// downRef returns the DBNO of victim if they are in DBNO state.
+func (r *Reader) downRef(victim string) (DownRef, bool) {
+	i, ok := r.dbnoIndex[victim]
+	if !ok || i >= len(r.MatchFeedback) {
+		return DownRef{}, false
+	}
+	u := r.MatchFeedback[i]
+	return DownRef{
+		Index:         i,
+		Username:      u.Username,
+		Time:          u.Time,
+		TimeInSeconds: u.TimeInSeconds,
+	}, true
+}
+
+// revive records a Revive of username if they are in DBNO state.
+// There is no revive packet, so revives are only recorded when a downed
+// player outlives the bleed-out window. Downed players can still be
+// credited with kills, e.g. by their C4, so attacking is not a revive.
+func (r *Reader) revive(username string) error {
+	down, ok := r.downRef(username)
+	if !ok {
+		return nil
+	}
+	r.ClearDBNOState(username)
+	u := MatchUpdate{
+		Type:          Revive,
+		Username:      username,
+		Time:          r.timeRaw,
+		TimeInSeconds: r.time,
+		Down:          &down,
+	}
+	if err := r.addFeedback(u); err != nil {
+		return err
+	}
+	log.Debug().Interface("match_update", u).Send()
+	return nil
+}
+
+// duplicateDBNO reports whether a DBNO of target by username repeats the DBNO
+// target is in, rather than downing them again after a revive.
+func (r *Reader) duplicateDBNO(username string, target string) bool {
+	down, ok := r.downRef(target)
+	return ok && down.Username == username && r.elapsed-r.dbnoElapsed[target] < duplicateWindow
+}
+
+// expireDowns records a Revive of every player downed for longer than
+// bleedOutWindow. They would have bled out by now, so they must have
+// been revived. The Revive is stamped with the first clock tick after the
+// window ran out, not with the time of the revive itself.
+func (r *Reader) expireDowns() error {
+	expired := make([]string, 0)
+	for victim, elapsed := range r.dbnoElapsed {
+		if r.elapsed-elapsed > bleedOutWindow {
+			expired = append(expired, victim)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return r.dbnoIndex[expired[i]] < r.dbnoIndex[expired[j]]
+	})
+	for _, victim := range expired {
+		if err := r.revive(victim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -25,11 +25,7 @@ func (r *Reader) getAlivePlayersByTeam(teamIndex int) []string {
 			// Check if player has died
 			died := false
 			for _, fb := range r.MatchFeedback {
-				if fb.Type == Kill && fb.Target == p.Username {
-					died = true
-					break
-				}
-				if fb.Type == Death && fb.Username == p.Username {
+				if victim, ok := fb.victim(); ok && victim == p.Username {
 					died = true
 					break
 				}
@@ -57,11 +53,8 @@ func (r *Reader) findAlivePlayersOfRole(role TeamRole) []int {
 			// This event happened AFTER current time in the round
 			continue
 		}
-		if update.Type == Kill && update.Target != "" {
-			deadPlayers[update.Target] = true
-		}
-		if update.Type == Death {
-			deadPlayers[update.Username] = true
+		if victim, ok := update.victim(); ok {
+			deadPlayers[victim] = true
 		}
 	}
 
@@ -94,7 +87,8 @@ func readDefuserTimer(r *Reader) error {
 
 	var i int = -1
 
-	if r.Header.CodeVersion >= Y10S4 {
+	offset, hasID := r.layout(PacketDefuserTimer).Offsets["playerID"]
+	if !hasID {
 		// Y10S4 changed packet structure - player DissectID is no longer included
 		// Try to infer from team roles: attackers plant, defenders disable
 		var targetRole TeamRole
@@ -118,7 +112,7 @@ func readDefuserTimer(r *Reader) error {
 			}
 		}
 	} else {
-		if err = r.Skip(34); err != nil {
+		if err = r.Skip(offset); err != nil {
 			return err
 		}
 		id, err := r.Bytes(4)
@@ -148,7 +142,9 @@ func readDefuserTimer(r *Reader) error {
 			Time:          r.timeRaw,
 			TimeInSeconds: r.time,
 		}
-		r.MatchFeedback = append(r.MatchFeedback, u)
+		if err := r.addFeedback(u); err != nil {
+			return err
+		}
 		log.Debug().Interface("match_update", u).Send()
 		r.lastDefuserPlayerIndex = i
 	}
@@ -228,7 +224,9 @@ func readDefuserTimer(r *Reader) error {
 		Time:          r.timeRaw,
 		TimeInSeconds: r.time,
 	}
-	r.MatchFeedback = append(r.MatchFeedback, u)
+	if err := r.addFeedback(u); err != nil {
+		return err
+	}
 	log.Debug().Interface("match_update", u).Send()
 
 	r.lastDefuserTimer = timerValue
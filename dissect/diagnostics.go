@@ -0,0 +1,71 @@
+package dissect
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Diagnostic describes an anomaly found while reading a replay.
+type Diagnostic struct {
+	Listener      string   `json:"listener"` // function that reported the anomaly
+	Offset        int      `json:"offset"`   // offset after the packet marker in the decompressed replay
+	Time          string   `json:"time"`
+	TimeInSeconds float64  `json:"timeInSeconds"`
+	Severity      Severity `json:"severity"`
+	Message       string   `json:"message"`
+}
+
+// diagnose records a diagnostic for the packet currently being read.
+func (r *Reader) diagnose(severity Severity, msg string) {
+	r.Diagnostics = append(r.Diagnostics, Diagnostic{
+		Listener:      r.listenerName(),
+		Offset:        r.packetOffset,
+		Time:          r.timeRaw,
+		TimeInSeconds: r.time,
+		Severity:      severity,
+		Message:       msg,
+	})
+}
+
+// listenerName returns the name of the running listener,
+// or of the caller outside of listeners (e.g. roundEnd).
+func (r *Reader) listenerName() string {
+	var pc uintptr
+	if r.listener != nil {
+		pc = reflect.ValueOf(r.listener).Pointer()
+	} else if caller, _, _, ok := runtime.Caller(2); ok {
+		pc = caller
+	}
+	details := runtime.FuncForPC(pc)
+	if details == nil {
+		return ""
+	}
+	name := details.Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// skippable records a listener error and reports whether Read can continue.
+// In Lenient mode every listener error except cancellation is skipped.
+func (r *Reader) skippable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	severity := SeverityError
+	if errors.Is(err, io.EOF) {
+		severity = SeverityInfo // packet truncated at the end of the replay
+	}
+	r.diagnose(severity, err.Error())
+	return r.Lenient
+}
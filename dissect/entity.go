@@ -0,0 +1,176 @@
+package dissect
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// EntityKind classifies a non-player entity seen in the position stream.
+type EntityKind string
+
+const (
+	EntityDrone     EntityKind = "Drone"     // moves along one floor for a while
+	EntityGadget    EntityKind = "Gadget"    // stays where it was deployed
+	EntityThrowable EntityKind = "Throwable" // moves briefly, e.g. grenades
+	EntityUnknown   EntityKind = "Unknown"
+)
+
+// Entity classification thresholds.
+const (
+	entityMinSightings    = 3   // fewer position packets are treated as noise
+	entityStationary      = 0.5 // max XY spread in meters of a deployed gadget
+	entityThrowableSecs   = 6.0 // max lifetime in seconds of a thrown gadget
+	entityDroneFloorSpan  = 1.0 // max Z spread in meters of a drone
+	entityOwnerRadius     = 3.0 // max distance in meters from the owner at first sighting
+	entityOwnerFloorRange = 2.0 // max Z distance in meters from the owner at first sighting
+)
+
+// EntityMovement is the trajectory of a non-player entity, such as a drone,
+// a deployed gadget or thrown utility, throughout a round.
+type EntityMovement struct {
+	EntityID   uint32           `json:"entityID"`
+	Kind       EntityKind       `json:"kind"`
+	PacketType string           `json:"packetType"`         // type bytes of the position packets, e.g. "B801"
+	Owner      string           `json:"owner,omitempty"`    // nearest tracked player at the first sighting
+	Operator   string           `json:"operator,omitempty"` // operator of the owner
+	Positions  []PlayerPosition `json:"positions"`
+}
+
+// entityTrack is the position packets of a non-player entity ID.
+type entityTrack struct {
+	entityID   uint32
+	packetType uint16
+	positions  []rawPosition
+}
+
+// isPlayerID reports whether the position packet player ID belongs to a header player.
+func (r *Reader) isPlayerID(playerID uint32) bool {
+	idx := int(playerID) - 5
+	return idx >= 0 && idx < len(r.Header.Players)
+}
+
+// trackEntity records a position packet of a non-player entity.
+func (r *Reader) trackEntity(packetNum int, entityID uint32, typeFirst, typeSecond byte, x, y, z float32) {
+	t, ok := r.entityTracks[entityID]
+	if !ok {
+		t = &entityTrack{
+			entityID:   entityID,
+			packetType: uint16(typeFirst)<<8 | uint16(typeSecond),
+		}
+		r.entityTracks[entityID] = t
+	}
+	t.positions = append(t.positions, rawPosition{
+		packetNum: packetNum,
+		time:      r.time,
+		entityID:  entityID,
+		x:         x,
+		y:         y,
+		z:         z,
+	})
+}
+
+// peekEntity records a position packet of a type without a known player
+// structure, if its coordinates are valid. The offset is not advanced.
+func (r *Reader) peekEntity(packetNum int, entityID uint32, typeFirst, typeSecond byte) {
+	if r.offset+12 > len(r.b) {
+		return
+	}
+	x := readFloat32LE(r.b[r.offset:])
+	y := readFloat32LE(r.b[r.offset+4:])
+	z := readFloat32LE(r.b[r.offset+8:])
+	if !isValidWorldCoord(x) || !isValidWorldCoord(y) || !isValidWorldCoord(z) || z < -10 || z > 50 {
+		return
+	}
+	r.trackEntity(packetNum, entityID, typeFirst, typeSecond, x, y, z)
+}
+
+// nearestPlayer returns the index in movements of the player track last seen
+// closest to the first sighting of the entity within entityOwnerRadius, or -1.
+// Tracks are assigned to players by movement, so the unreliable player IDs of
+// the position packets are not used.
+func (t *entityTrack) nearestPlayer(movements []PlayerMovement, clock packetClock) int {
+	first := t.positions[0]
+	best, bestDist := -1, entityOwnerRadius
+	for i, m := range movements {
+		// last position of the player at or before the first sighting
+		j := sort.SearchInts(m.packets, first.packetNum+1) - 1
+		if j < 0 || clock.elapsed(first.packetNum)-m.Positions[j].ElapsedSeconds > maxTrackingGap {
+			continue
+		}
+		pos := m.Positions[j]
+		if math.Abs(float64(pos.Z-first.z)) > entityOwnerFloorRange {
+			continue
+		}
+		if d := math.Hypot(float64(pos.X-first.x), float64(pos.Y-first.y)); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// classify returns the kind of the entity from its lifetime and movement.
+// The packet type is not used: which types belong to which kinds of entities
+// is not known yet, so it is only reported in EntityMovement.PacketType.
+func (t *entityTrack) classify(clock packetClock) EntityKind {
+	first, last := t.positions[0], t.positions[len(t.positions)-1]
+	minX, maxX, minY, maxY, minZ, maxZ := first.x, first.x, first.y, first.y, first.z, first.z
+	for _, p := range t.positions {
+		minX, maxX = min(minX, p.x), max(maxX, p.x)
+		minY, maxY = min(minY, p.y), max(maxY, p.y)
+		minZ, maxZ = min(minZ, p.z), max(maxZ, p.z)
+	}
+	switch lifetime := clock.elapsed(last.packetNum) - clock.elapsed(first.packetNum); {
+	case maxX-minX < entityStationary && maxY-minY < entityStationary:
+		return EntityGadget
+	case lifetime <= entityThrowableSecs:
+		return EntityThrowable
+	case maxZ-minZ < entityDroneFloorSpan:
+		return EntityDrone
+	}
+	return EntityUnknown
+}
+
+// GetEntityMovements returns the trajectories of the non-player entities seen
+// in the position stream, ordered by first sighting. Only populated when
+// movement tracking is enabled.
+func (r *Reader) GetEntityMovements() []EntityMovement {
+	if len(r.entityTracks) == 0 {
+		return nil
+	}
+	clock := r.newPacketClock()
+	tracks := make([]*entityTrack, 0, len(r.entityTracks))
+	for _, t := range r.entityTracks {
+		if len(t.positions) >= entityMinSightings {
+			tracks = append(tracks, t)
+		}
+	}
+	sort.Slice(tracks, func(i, j int) bool {
+		return tracks[i].positions[0].packetNum < tracks[j].positions[0].packetNum
+	})
+	movements := r.GetMovementData()
+	result := make([]EntityMovement, 0, len(tracks))
+	for _, t := range tracks {
+		m := EntityMovement{
+			EntityID:   t.entityID,
+			Kind:       t.classify(clock),
+			PacketType: fmt.Sprintf("%04X", t.packetType),
+			Positions:  make([]PlayerPosition, 0, len(t.positions)),
+		}
+		if i := t.nearestPlayer(movements, clock); i >= 0 {
+			m.Owner = movements[i].Username
+			m.Operator = movements[i].Operator
+		}
+		for _, p := range t.positions {
+			m.Positions = append(m.Positions, PlayerPosition{
+				TimeInSeconds:  clock.roundTime(p.packetNum),
+				ElapsedSeconds: clock.elapsed(p.packetNum),
+				X:              p.x,
+				Y:              p.y,
+				Z:              p.z,
+			})
+		}
+		result = append(result, m)
+	}
+	return result
+}
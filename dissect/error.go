@@ -2,7 +2,9 @@ package dissect
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"path/filepath"
 
 	"github.com/klauspost/compress/zstd"
 )
@@ -10,6 +12,23 @@ import (
 var ErrInvalidFile = errors.New("dissect: not a dissect file")
 var ErrInvalidFolder = errors.New("dissect: not a match folder")
 var ErrInvalidStringSep = errors.New("dissect: invalid string separator")
+var ErrNotRetained = errors.New("dissect: replay not retained, set Retain before Read")
+var ErrNoRooms = errors.New("dissect: no room geometry for map")
+
+// RoundError is returned by MatchReader.Read for a round that failed to parse.
+type RoundError struct {
+	Round int    // zero-based round index
+	Path  string // round file
+	Err   error
+}
+
+func (e *RoundError) Error() string {
+	return fmt.Sprintf("dissect: round %d (%s): %v", e.Round+1, filepath.Base(e.Path), e.Err)
+}
+
+func (e *RoundError) Unwrap() error {
+	return e.Err
+}
 
 // Ok returns true if err only pertains to EOF (read was successful).
 func Ok(err error) bool {
@@ -0,0 +1,145 @@
+package dissect
+
+import "reflect"
+
+// Event is implemented by the typed events emitted during Read.
+// Subscribe to a concrete event type with Subscribe.
+type Event interface {
+	event()
+}
+
+// KillEvent is emitted when a kill is decoded from the match feedback.
+type KillEvent struct{ MatchUpdate }
+
+// DeathEvent is emitted when a player dies without an attacker (e.g. fall damage).
+type DeathEvent struct{ MatchUpdate }
+
+// DBNOEvent is emitted when a player is downed.
+type DBNOEvent struct{ MatchUpdate }
+
+// ReviveEvent is emitted when a downed player outlives the bleed-out window, so must have been revived.
+type ReviveEvent struct{ MatchUpdate }
+
+// BleedOutEvent is emitted when a downed player dies without being finished off.
+type BleedOutEvent struct{ MatchUpdate }
+
+// RoundEndEvent is emitted once the outcome of the round is decided, after the replay is read.
+type RoundEndEvent struct{ MatchUpdate }
+
+// ObjectiveEvent is emitted for the Secure Area and Hostage objective updates.
+// Apart from the hostage DBNOs and kills, they are only read from replays before Y9S1.
+// Type distinguishes the actions.
+type ObjectiveEvent struct{ MatchUpdate }
+
+// DefuserEvent is emitted when a defuser plant or disable starts or completes.
+// Type distinguishes the four actions.
+type DefuserEvent struct{ MatchUpdate }
+
+// OperatorSwapEvent is emitted when an attacker swaps operator during prep phase.
+type OperatorSwapEvent struct{ MatchUpdate }
+
+// FeedbackEvent is emitted for match feedback without a dedicated event type,
+// such as objective locates, BattlEye bans and players leaving.
+type FeedbackEvent struct{ MatchUpdate }
+
+// AmmoEvent is emitted for every ammo update attributed to a player.
+type AmmoEvent struct{ AmmoUpdate }
+
+// AbilityEvent is emitted when a charge of an operator ability is used.
+type AbilityEvent struct{ AbilityUpdate }
+
+// PositionEvent is emitted for every decoded position packet. Position packets are
+// only decoded with TrackMovement enabled or a PositionEvent subscriber registered.
+type PositionEvent struct {
+	PacketNum     int     `json:"packetNum"`
+	EntityID      uint32  `json:"entityID"`
+	PlayerID      uint32  `json:"playerID"` // maps to header index via playerID-5
+	X             float32 `json:"x"`
+	Y             float32 `json:"y"`
+	Z             float32 `json:"z"`
+	Yaw           float32 `json:"yaw,omitempty"`
+	Time          string  `json:"time"`
+	TimeInSeconds float64 `json:"timeInSeconds"`
+}
+
+// TimeTickEvent is emitted whenever the round clock changes.
+type TimeTickEvent struct {
+	Time          string  `json:"time"`
+	TimeInSeconds float64 `json:"timeInSeconds"`
+}
+
+func (KillEvent) event()         {}
+func (DeathEvent) event()        {}
+func (DBNOEvent) event()         {}
+func (ReviveEvent) event()       {}
+func (BleedOutEvent) event()     {}
+func (RoundEndEvent) event()     {}
+func (ObjectiveEvent) event()    {}
+func (DefuserEvent) event()      {}
+func (OperatorSwapEvent) event() {}
+func (FeedbackEvent) event()     {}
+func (AmmoEvent) event()         {}
+func (AbilityEvent) event()      {}
+func (PositionEvent) event()     {}
+func (TimeTickEvent) event()     {}
+func (RoomChangeEvent) event()   {}
+
+// Subscribe registers handler to be called during Read whenever an event
+// of type E is decoded. Events are emitted in replay order, except
+// RoomChangeEvent, which is emitted once the replay is read. Returning an
+// error from handler stops the Read, like a Listen callback.
+func Subscribe[E Event](r *Reader, handler func(e E) error) {
+	if r.subscribers == nil {
+		r.subscribers = make(map[reflect.Type][]func(Event) error)
+	}
+	t := reflect.TypeFor[E]()
+	r.subscribers[t] = append(r.subscribers[t], func(e Event) error {
+		return handler(e.(E))
+	})
+}
+
+// subscribed reports whether any handler is registered for the type of e.
+func (r *Reader) subscribed(e Event) bool {
+	return len(r.subscribers[reflect.TypeOf(e)]) > 0
+}
+
+// emit passes e to every handler subscribed to its type.
+func (r *Reader) emit(e Event) error {
+	for _, handler := range r.subscribers[reflect.TypeOf(e)] {
+		if err := handler(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addFeedback records a match update and emits the matching typed event.
+func (r *Reader) addFeedback(u MatchUpdate) error {
+	r.MatchFeedback = append(r.MatchFeedback, u)
+	return r.emit(feedbackEvent(u))
+}
+
+func feedbackEvent(u MatchUpdate) Event {
+	switch u.Type {
+	case Kill:
+		return KillEvent{u}
+	case Death:
+		return DeathEvent{u}
+	case DBNO:
+		return DBNOEvent{u}
+	case Revive:
+		return ReviveEvent{u}
+	case BleedOut:
+		return BleedOutEvent{u}
+	case RoundEnd:
+		return RoundEndEvent{u}
+	case DefuserPlantStart, DefuserPlantComplete, DefuserDisableStart, DefuserDisableComplete:
+		return DefuserEvent{u}
+	case OperatorSwap:
+		return OperatorSwapEvent{u}
+	}
+	if isObjective(u.Type) {
+		return ObjectiveEvent{u}
+	}
+	return FeedbackEvent{u}
+}
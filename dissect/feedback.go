@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -25,6 +26,18 @@ const (
 	Battleye
 	PlayerLeave
 	Other
+	Revive   // a downed player was revived
+	BleedOut // a downed player died without being finished off
+	RoundEnd // the round was decided
+	// objective messages, only carried by replays before Y9S1
+	AreaContested
+	AreaSecured
+	HostagePickup
+	HostageDrop
+	HostageExtracted
+	// hostage harm from the kill feed
+	HostageDBNO
+	HostageKill
 )
 
 type MatchUpdate struct {
@@ -32,6 +45,14 @@ type MatchUpdate struct {
 	Username               string          `json:"username,omitempty"`
 	Target                 string          `json:"target,omitempty"`
 	Headshot               *bool           `json:"headshot,omitempty"`
+	Cause                  KillCause       `json:"cause,omitempty"`
+	Down                   *DownRef        `json:"down,omitempty"`     // DBNO that led to a Kill, Revive or BleedOut
+	Finisher               string          `json:"finisher,omitempty"` // player who finished off a downed target
+	TeamKill               bool            `json:"teamKill,omitempty"` // Kill or DBNO of a teammate
+	Suicide                bool            `json:"suicide,omitempty"`  // Kill or DBNO of the attacker themself
+	Role                   TeamRole        `json:"role,omitempty"`     // winning role of a RoundEnd
+	WinCondition           WinCondition    `json:"winCondition,omitempty"`
+	Alive                  []int           `json:"alive,omitempty"` // players alive per team index at RoundEnd
 	Time                   string          `json:"time"`
 	TimeInSeconds          float64         `json:"timeInSeconds"`
 	Message                string          `json:"message,omitempty"`
@@ -39,6 +60,40 @@ type MatchUpdate struct {
 	usernameFromScoreboard string
 }
 
+// victim returns the player who died in a Kill, Death or BleedOut update.
+func (u MatchUpdate) victim() (string, bool) {
+	switch u.Type {
+	case Kill:
+		return u.Target, u.Target != ""
+	case Death, BleedOut:
+		return u.Username, true
+	}
+	return "", false
+}
+
+// countedKill reports whether u is a Kill counted in kill totals.
+// Team kills and suicides are not.
+func (u MatchUpdate) countedKill() bool {
+	return u.Type == Kill && !u.TeamKill && !u.Suicide
+}
+
+// flagTeamDamage flags a Kill or DBNO credited to the target themself or to
+// a teammate. A self-down finished by an enemy is credited to the target, so
+// it is a suicide.
+func (r *Reader) flagTeamDamage(u *MatchUpdate) {
+	if u.Username == u.Target {
+		u.Suicide = true
+		u.Cause = KillCauseSuicide
+		return
+	}
+	i := r.PlayerIndexByUsername(u.Username)
+	j := r.PlayerIndexByUsername(u.Target)
+	if i < 0 || j < 0 {
+		return
+	}
+	u.TeamKill = r.Header.Players[i].TeamIndex == r.Header.Players[j].TeamIndex
+}
+
 func (i MatchUpdateType) MarshalJSON() (text []byte, err error) {
 	return json.Marshal(stringerIntMarshal{
 		Name: i.String(),
@@ -66,12 +121,9 @@ const (
 )
 
 func readMatchFeedback(r *Reader) error {
-	if r.Header.CodeVersion >= Y9S1Update3 {
-		if err := r.Skip(38); err != nil {
-			return err
-		}
-	} else if r.Header.CodeVersion >= Y9S1 {
-		if err := r.Skip(9); err != nil {
+	l := r.layout(PacketMatchFeedback)
+	if n, ok := l.Offsets["valid"]; ok {
+		if err := r.Skip(n); err != nil {
 			return err
 		}
 		valid, err := r.Int()
@@ -81,14 +133,12 @@ func readMatchFeedback(r *Reader) error {
 		if valid != 4 {
 			return errors.New("match feedback failed valid check")
 		}
-		if err := r.Skip(24); err != nil {
-			return err
-		}
-	} else {
-		if err := r.Skip(1); err != nil {
-			return err
-		}
-		if err := r.Seek(activity2); err != nil {
+	}
+	if err := r.Skip(l.Offsets["header"]); err != nil {
+		return err
+	}
+	if activity, ok := l.Indicators["activity"]; ok {
+		if err := r.Seek(activity); err != nil {
 			return err
 		}
 	}
@@ -103,6 +153,7 @@ func readMatchFeedback(r *Reader) error {
 		}
 		if !bytes.Equal(killTrace, killIndicator) {
 			log.Debug().Hex("killTrace", killTrace).Send()
+			r.diagnose(SeverityInfo, fmt.Sprintf("unknown match feedback indicator %X", killTrace))
 			return nil
 		}
 		username, err := r.String()
@@ -112,6 +163,7 @@ func readMatchFeedback(r *Reader) error {
 		empty := len(username) == 0
 		if empty {
 			log.Debug().Str("warn", "kill/DBNO username empty").Send()
+			r.diagnose(SeverityWarning, "kill/DBNO username empty")
 		}
 		// These 15 bytes contain kill type info - byte[6] distinguishes DBNO vs Kill
 		typeBytes, err := r.Bytes(15)
@@ -127,6 +179,10 @@ func readMatchFeedback(r *Reader) error {
 		}
 		log.Debug().Str("target", target).Uint8("killType", killType).Msg("kill/dbno target parsed")
 
+		if r.Header.GameMode == Hostage && strings.EqualFold(target, hostageName) {
+			return r.readHostageHarm(username, killType)
+		}
+
 		// Handle death with no attacker (suicide, fall damage, etc.)
 		if empty && len(target) > 0 {
 			u := MatchUpdate{
@@ -134,37 +190,62 @@ func readMatchFeedback(r *Reader) error {
 				Username:      target,
 				Time:          r.timeRaw,
 				TimeInSeconds: r.time,
+				Cause:         KillCauseUnknown,
+			}
+			if down, downed := r.downRef(target); downed {
+				u.Type = BleedOut
+				u.Cause = KillCauseBleedOut
+				u.Down = &down
+				r.ClearDBNOState(target)
+			}
+			if err := r.addFeedback(u); err != nil {
+				return err
 			}
-			r.MatchFeedback = append(r.MatchFeedback, u)
 			log.Debug().Interface("match_update", u).Send()
 			log.Debug().Msg("kill username empty because of death")
 			return nil
 		} else if empty {
+			r.diagnose(SeverityWarning, "kill/DBNO target empty")
 			return nil
 		}
 
 		// Handle DBNO event (killType = 0x01)
 		if killType == killTypeDBNO {
+			if r.duplicateDBNO(username, target) {
+				log.Debug().Str("username", username).Str("target", target).Msg("duplicate DBNO filtered")
+				r.diagnose(SeverityInfo, fmt.Sprintf("duplicate DBNO filtered: %s -> %s", username, target))
+				return nil
+			}
 			u := MatchUpdate{
 				Type:          DBNO,
 				Username:      username,
 				Target:        target,
 				Time:          r.timeRaw,
 				TimeInSeconds: r.time,
+				Cause:         r.weaponCause(username),
 			}
+			r.flagTeamDamage(&u)
 			// Track who downed the target
 			r.dbnoState[target] = username
-			r.MatchFeedback = append(r.MatchFeedback, u)
+			r.dbnoIndex[target] = len(r.MatchFeedback)
+			r.dbnoElapsed[target] = r.elapsed
+			if err := r.addFeedback(u); err != nil {
+				return err
+			}
 			log.Debug().Interface("match_update", u).Str("dbno_tracker", "recorded").Send()
 			return nil
 		}
 
 		// Handle Kill event - check if victim was DBNO'd and credit original downer
 		killCredit := username
-		if downer, wasDowned := r.dbnoState[target]; wasDowned {
-			killCredit = downer
-			log.Debug().Str("original_killer", username).Str("credited_to", downer).Str("victim", target).Msg("kill credit redirected to downer")
-			delete(r.dbnoState, target) // Clear DBNO state for this player
+		cause := r.weaponCause(username)
+		down, wasDowned := r.downRef(target)
+		if wasDowned {
+			killCredit = down.Username
+			// credit the weapon that downed the target
+			cause = r.MatchFeedback[down.Index].Cause
+			log.Debug().Str("original_killer", username).Str("credited_to", down.Username).Str("victim", target).Msg("kill credit redirected to downer")
+			r.ClearDBNOState(target)
 		}
 
 		u := MatchUpdate{
@@ -173,7 +254,13 @@ func readMatchFeedback(r *Reader) error {
 			Target:        target,
 			Time:          r.timeRaw,
 			TimeInSeconds: r.time,
+			Cause:         cause,
+		}
+		if wasDowned {
+			u.Down = &down
+			u.Finisher = username
 		}
+		r.flagTeamDamage(&u)
 		if err = r.Skip(56); err != nil {
 			return err
 		}
@@ -190,6 +277,7 @@ func readMatchFeedback(r *Reader) error {
 		for _, val := range r.MatchFeedback {
 			if val.Type == Kill && val.Username == u.Username && val.Target == u.Target {
 				log.Debug().Str("username", u.Username).Str("target", u.Target).Msg("duplicate kill filtered")
+				r.diagnose(SeverityInfo, fmt.Sprintf("duplicate kill filtered: %s -> %s", u.Username, u.Target))
 				return nil
 			}
 		}
@@ -197,14 +285,16 @@ func readMatchFeedback(r *Reader) error {
 		if r.lastKillerFromScoreboard != killCredit {
 			u.usernameFromScoreboard = r.lastKillerFromScoreboard
 		}
-		r.MatchFeedback = append(r.MatchFeedback, u)
+		if err := r.addFeedback(u); err != nil {
+			return err
+		}
 		log.Debug().Interface("match_update", u).Send()
 		return nil
 	}
-	// TODO: Y9S1 may have removed or modified other match feedback options
-	if r.Header.CodeVersion >= Y9S1 {
+	if !l.Flags["messages"] {
 		return nil
 	}
+	r.messageSpans = append(r.messageSpans, [2]int{r.position(), size})
 	b, err := r.Bytes(size)
 	if err != nil {
 		return err
@@ -221,9 +311,14 @@ func readMatchFeedback(r *Reader) error {
 		t = PlayerLeave
 	}
 	username := strings.Split(msg, " ")[0]
+	if t != PlayerLeave && t != Battleye {
+		if objective, player, ok := r.objectiveMessage(msg); ok {
+			t, username = objective, player
+		}
+	}
 	if t == Other {
 		username = ""
-	} else {
+	} else if !isObjective(t) {
 		msg = ""
 	}
 	u := MatchUpdate{
@@ -234,7 +329,9 @@ func readMatchFeedback(r *Reader) error {
 		TimeInSeconds: r.time,
 		Message:       msg,
 	}
-	r.MatchFeedback = append(r.MatchFeedback, u)
+	if err := r.addFeedback(u); err != nil {
+		return err
+	}
 	log.Debug().Interface("match_update", u).Send()
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -126,11 +127,13 @@ const (
 	ConsulateY10           Map = 418126004176
 
 	KilledOpponents  WinCondition = "KilledOpponents"
-	SecuredArea      WinCondition = "SecuredArea" // TODO
+	SecuredArea      WinCondition = "SecuredArea"
 	DisabledDefuser  WinCondition = "DisabledDefuser"
 	DefusedBomb      WinCondition = "DefusedBomb"
-	ExtractedHostage WinCondition = "ExtractedHostage" // TODO
+	ExtractedHostage WinCondition = "ExtractedHostage"
+	KilledHostage    WinCondition = "KilledHostage" // the opponents killed the hostage
 	Time             WinCondition = "Time"
+	Forfeit          WinCondition = "Forfeit" // every opponent left the match
 
 	Attack  TeamRole = "Attack"
 	Defense TeamRole = "Defense"
@@ -536,12 +539,23 @@ func (r *Reader) readHeader() (Header, error) {
 	return h, nil
 }
 
+// deriveWinner sets the winning team from the score change (Y9S4+).
+func (h *Header) deriveWinner() {
+	if h.CodeVersion < Y9S4 {
+		return
+	}
+	team0Won := h.Teams[0].StartingScore < h.Teams[0].Score
+	h.Teams[0].Won = team0Won
+	h.Teams[1].Won = !team0Won
+}
+
 // deriveTeamRoles uses the operators chosen by the players to
 // determine the team roles
 func (r *Reader) deriveTeamRoles() {
 	log.Debug().Int("players", len(r.Header.Players)).Msg("deriving team roles")
 	if len(r.Header.Players) > 10 {
 		log.Warn().Msg("tracked players greater than 10")
+		r.diagnose(SeverityWarning, fmt.Sprintf("tracked %d players, expected at most 10", len(r.Header.Players)))
 	}
 	players := r.Header.Players[:0]
 	for _, p := range r.Header.Players {
@@ -553,6 +567,7 @@ func (r *Reader) deriveTeamRoles() {
 			})
 		} else {
 			log.Warn().Str("username", p.Username).Msg("operator id was 0, removing from list")
+			r.diagnose(SeverityWarning, fmt.Sprintf("operator id was 0 for %q, removing from list", p.Username))
 		}
 	}
 	r.Header.Players = players
@@ -0,0 +1,103 @@
+package dissect
+
+import (
+	"context"
+	"io"
+)
+
+// HeaderField names a Header field by its JSON key.
+type HeaderField string
+
+const (
+	HeaderGameVersion            HeaderField = "gameVersion"
+	HeaderCodeVersion            HeaderField = "codeVersion"
+	HeaderTimestamp              HeaderField = "timestamp"
+	HeaderMatchType              HeaderField = "matchType"
+	HeaderMap                    HeaderField = "map"
+	HeaderRecordingPlayerID      HeaderField = "recordingPlayerID"
+	HeaderRecordingProfileID     HeaderField = "recordingProfileID"
+	HeaderAdditionalTags         HeaderField = "additionalTags"
+	HeaderGameMode               HeaderField = "gamemode"
+	HeaderRoundsPerMatch         HeaderField = "roundsPerMatch"
+	HeaderRoundsPerMatchOvertime HeaderField = "roundsPerMatchOvertime"
+	HeaderRoundNumber            HeaderField = "roundNumber"
+	HeaderOvertimeRoundNumber    HeaderField = "overtimeRoundNumber"
+	HeaderTeamNames              HeaderField = "teams.name"
+	HeaderTeamScores             HeaderField = "teams.score"
+	HeaderTeamStartingScores     HeaderField = "teams.startingScore"
+	HeaderTeamWon                HeaderField = "teams.won"
+	HeaderTeamRoles              HeaderField = "teams.role"
+	HeaderPlayers                HeaderField = "players"
+	HeaderPlayerProfileIDs       HeaderField = "players.profileID"
+	HeaderPlayerOperators        HeaderField = "players.operator"
+	HeaderPlayerSpawns           HeaderField = "players.spawn"
+	HeaderGMSettings             HeaderField = "gmSettings"
+	HeaderPlaylistCategory       HeaderField = "playlistCategory"
+	HeaderMatchID                HeaderField = "matchID"
+)
+
+// ReadHeaderOnly decodes the dissect header of a replay without decompressing
+// the body, e.g. to build a catalogue of replays quickly. With players set, the
+// first zstd section is decompressed to complete the player list with operators,
+// spawns and team roles. The returned fields list the Header fields decoded
+// from the replay; the others are left empty.
+func ReadHeaderOnly(in io.Reader, players bool) (h Header, fields []HeaderField, err error) {
+	r, err := newStreamReader(in)
+	if err != nil {
+		return h, nil, err
+	}
+	if players {
+		if s, ok := r.src.(*chunkedSource); ok {
+			s.maxSections = 1
+		}
+		r.listenLayout(PacketPlayer, readPlayer)
+		if err = r.ReadPartialContext(context.Background()); !Ok(err) {
+			return r.Header, nil, err
+		}
+	}
+	r.Header.deriveWinner()
+	return r.Header, r.Header.fields(), nil
+}
+
+// fields returns the fields of h holding decoded values.
+func (h Header) fields() []HeaderField {
+	fields := make([]HeaderField, 0)
+	add := func(f HeaderField, ok bool) {
+		if ok {
+			fields = append(fields, f)
+		}
+	}
+	add(HeaderGameVersion, h.GameVersion != "")
+	add(HeaderCodeVersion, h.CodeVersion != 0)
+	add(HeaderTimestamp, !h.Timestamp.IsZero())
+	add(HeaderMatchType, h.MatchType != 0)
+	add(HeaderMap, h.Map != 0)
+	add(HeaderRecordingPlayerID, h.RecordingPlayerID != 0)
+	add(HeaderRecordingProfileID, h.RecordingProfileID != "")
+	add(HeaderAdditionalTags, h.AdditionalTags != "")
+	add(HeaderGameMode, h.GameMode != 0)
+	add(HeaderRoundsPerMatch, h.RoundsPerMatch != 0)
+	add(HeaderRoundsPerMatchOvertime, h.RoundsPerMatchOvertime != 0)
+	// round numbers are zero-based
+	add(HeaderRoundNumber, true)
+	add(HeaderOvertimeRoundNumber, true)
+	add(HeaderTeamNames, h.Teams[0].Name != "" || h.Teams[1].Name != "")
+	add(HeaderTeamScores, true)
+	add(HeaderTeamStartingScores, h.CodeVersion >= Y9S4)
+	add(HeaderTeamWon, h.CodeVersion >= Y9S4)
+	add(HeaderTeamRoles, h.Teams[0].Role != "" && h.Teams[1].Role != "")
+	add(HeaderPlayers, len(h.Players) > 0)
+	profileIDs, operators, spawns := len(h.Players) > 0, len(h.Players) > 0, false
+	for _, p := range h.Players {
+		profileIDs = profileIDs && p.ProfileID != ""
+		operators = operators && p.Operator != 0
+		spawns = spawns || p.Spawn != ""
+	}
+	add(HeaderPlayerProfileIDs, profileIDs)
+	add(HeaderPlayerOperators, operators)
+	add(HeaderPlayerSpawns, spawns)
+	add(HeaderGMSettings, len(h.GMSettings) > 0)
+	add(HeaderPlaylistCategory, h.PlaylistCategory != 0)
+	add(HeaderMatchID, h.MatchID != "")
+	return fields
+}
@@ -0,0 +1,317 @@
+package dissect
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"slices"
+	"sort"
+)
+
+// HeatmapLayer selects the points drawn by a Heatmap.
+type HeatmapLayer string
+
+const (
+	HeatPositions HeatmapLayer = "positions" // tracked player positions
+	HeatDeaths    HeatmapLayer = "deaths"    // where players died
+	HeatKills     HeatmapLayer = "kills"     // where killers, or finishers of downed players, stood at their kills
+)
+
+// Heatmap rendering settings, in meters.
+const (
+	heatRadius = 1.0 // standard deviation of the blur around each point
+	heatMargin = 3.0 // space around the points and rooms
+)
+
+var (
+	heatBackground = color.NRGBA{0x1E, 0x1E, 0x1E, 0xFF}
+	heatOutline    = color.NRGBA{0x70, 0x70, 0x70, 0xFF}
+	// heatRamp are the colors from the coldest to the hottest points.
+	heatRamp = []color.NRGBA{
+		{0x00, 0x00, 0xFF, 0xFF},
+		{0x00, 0xFF, 0xFF, 0xFF},
+		{0x00, 0xFF, 0x00, 0xFF},
+		{0xFF, 0xFF, 0x00, 0xFF},
+		{0xFF, 0x00, 0x00, 0xFF},
+	}
+)
+
+// HeatmapFilter limits the points of a Heatmap. Zero values match everything.
+// Positions are filtered by their player, deaths by the victim and kills by the killer.
+type HeatmapFilter struct {
+	Role      TeamRole // Attack or Defense
+	Players   []string // usernames
+	Operators []string // operator names, e.g., "Ash"
+	From      float64  // elapsed seconds since the first clock tick of the round
+	To        float64  // elapsed seconds, 0 for the end of the round
+}
+
+func (f HeatmapFilter) match(p PlayerState, elapsed float64) bool {
+	switch {
+	case f.Role != "" && string(f.Role) != p.Team:
+		return false
+	case len(f.Players) > 0 && !slices.Contains(f.Players, p.Username):
+		return false
+	case len(f.Operators) > 0 && !slices.Contains(f.Operators, p.Operator):
+		return false
+	case elapsed < f.From || (f.To > 0 && elapsed > f.To):
+		return false
+	}
+	return true
+}
+
+// HeatmapKey identifies the floor of a map drawn by a Heatmap.
+type HeatmapKey struct {
+	Map   string `json:"map"`
+	Floor string `json:"floor"`
+}
+
+// Heatmap accumulates points of rounds per map floor and renders them as images.
+// Positions are only tracked if movement tracking was enabled during Read.
+type Heatmap struct {
+	Layer  HeatmapLayer
+	Filter HeatmapFilter
+	points map[HeatmapKey][][2]float32 // x, y
+}
+
+// NewHeatmap returns an empty Heatmap of the layer.
+func NewHeatmap(layer HeatmapLayer, filter HeatmapFilter) *Heatmap {
+	return &Heatmap{
+		Layer:  layer,
+		Filter: filter,
+		points: make(map[HeatmapKey][][2]float32),
+	}
+}
+
+func (h *Heatmap) add(mapName string, x, y, z float32) {
+	key := HeatmapKey{Map: mapName, Floor: FloorAt(mapName, z)}
+	h.points[key] = append(h.points[key], [2]float32{x, y})
+}
+
+// AddRound adds the points of a round.
+func (h *Heatmap) AddRound(r *Reader) {
+	mapName := r.Header.Map.String()
+	if h.Layer == HeatPositions {
+		for _, m := range r.GetMovementData() {
+			for _, pos := range m.Positions {
+				p := PlayerState{Username: m.Username, Operator: m.Operator, Team: m.Team}
+				if h.Filter.match(p, pos.ElapsedSeconds) {
+					h.add(mapName, pos.X, pos.Y, pos.Z)
+				}
+			}
+		}
+		return
+	}
+	tl := r.Timeline()
+	for _, u := range r.MatchFeedback {
+		username, ok := u.victim()
+		if h.Layer == HeatKills {
+			username, ok = u.Username, u.Type == Kill && u.Username != ""
+			if u.Finisher != "" {
+				username = u.Finisher
+			}
+		}
+		if !ok {
+			continue
+		}
+		elapsed, found := tl.ElapsedAt(u.TimeInSeconds)
+		if !found {
+			continue
+		}
+		for _, p := range tl.At(elapsed).Players {
+			if p.Username == username && h.Filter.match(p, elapsed) {
+				h.add(mapName, p.X, p.Y, p.Z)
+			}
+		}
+	}
+}
+
+// AddMatch adds the points of every round of the match read so far.
+func (h *Heatmap) AddMatch(m *MatchReader) {
+	for i := range m.rounds {
+		if r := m.round(i); r != nil {
+			h.AddRound(r)
+		}
+	}
+}
+
+// Keys returns the map floors with points, ordered by map and floor.
+func (h *Heatmap) Keys() []HeatmapKey {
+	keys := make([]HeatmapKey, 0, len(h.points))
+	for k := range h.points {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Map != keys[j].Map {
+			return keys[i].Map < keys[j].Map
+		}
+		return keys[i].Floor < keys[j].Floor
+	})
+	return keys
+}
+
+// Len returns the number of points on the map floor.
+func (h *Heatmap) Len(k HeatmapKey) int {
+	return len(h.points[k])
+}
+
+// Image renders the points of the map floor the given pixels along its
+// longer side, with the outlines of its rooms if the map has room geometry.
+// North (increasing Y) is up.
+func (h *Heatmap) Image(k HeatmapKey, size int) *image.NRGBA {
+	points := h.points[k]
+	var rooms []Room
+	if g, ok := MapGeometryFor(k.Map); ok {
+		for _, room := range g.Rooms {
+			if room.Floor == k.Floor {
+				rooms = append(rooms, room)
+			}
+		}
+	}
+	minX, minY := float32(math.MaxFloat32), float32(math.MaxFloat32)
+	maxX, maxY := -minX, -minY
+	extend := func(p [2]float32) {
+		minX, maxX = min(minX, p[0]), max(maxX, p[0])
+		minY, maxY = min(minY, p[1]), max(maxY, p[1])
+	}
+	for _, p := range points {
+		extend(p)
+	}
+	for _, room := range rooms {
+		for _, p := range room.Polygon {
+			extend(p)
+		}
+	}
+	if minX > maxX {
+		minX, maxX, minY, maxY = 0, 0, 0, 0
+	}
+	minX, minY = minX-heatMargin, minY-heatMargin
+	maxX, maxY = maxX+heatMargin, maxY+heatMargin
+	size = max(size, 1)
+	scale := float64(size) / float64(max(maxX-minX, maxY-minY))
+	width, height := size, size
+	if maxX-minX < maxY-minY {
+		width = max(int(math.Ceil(float64(maxX-minX)*scale)), 1)
+	} else {
+		height = max(int(math.Ceil(float64(maxY-minY)*scale)), 1)
+	}
+	toPixel := func(p [2]float32) (int, int) {
+		return int(float64(p[0]-minX) * scale), int(float64(maxY-p[1]) * scale)
+	}
+
+	density := make([]float64, width*height)
+	for _, p := range points {
+		x, y := toPixel(p)
+		if x >= 0 && x < width && y >= 0 && y < height {
+			density[y*width+x]++
+		}
+	}
+	density = blur(density, width, height, heatRadius*scale)
+	peak := slices.Max(density)
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i, d := range density {
+		c := heatBackground
+		if peak > 0 && d > peak/100 {
+			c = blend(heatBackground, heatColor(d/peak), math.Sqrt(d/peak))
+		}
+		img.SetNRGBA(i%width, i/width, c)
+	}
+	for _, room := range rooms {
+		for i := range room.Polygon {
+			x0, y0 := toPixel(room.Polygon[i])
+			x1, y1 := toPixel(room.Polygon[(i+1)%len(room.Polygon)])
+			drawLine(img, x0, y0, x1, y1, heatOutline)
+		}
+	}
+	return img
+}
+
+// WritePNG writes the image of the map floor to out as a PNG. See Image.
+func (h *Heatmap) WritePNG(out io.Writer, k HeatmapKey, size int) error {
+	return png.Encode(out, h.Image(k, size))
+}
+
+// blur applies a gaussian blur with the standard deviation sigma in pixels.
+func blur(src []float64, width, height int, sigma float64) []float64 {
+	sigma = max(sigma, 1)
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+	}
+	pass := func(src []float64, n, stride, lines, step int) []float64 {
+		dst := make([]float64, len(src))
+		for line := range lines {
+			for i := range n {
+				v := src[line*step+i*stride]
+				if v == 0 {
+					continue
+				}
+				for k, w := range kernel {
+					if j := i + k - radius; j >= 0 && j < n {
+						dst[line*step+j*stride] += v * w
+					}
+				}
+			}
+		}
+		return dst
+	}
+	rows := pass(src, width, 1, height, width)
+	return pass(rows, height, width, width, 1)
+}
+
+// heatColor returns the color of the ramp at v (0-1).
+func heatColor(v float64) color.NRGBA {
+	v = math.Min(math.Max(v, 0), 1) * float64(len(heatRamp)-1)
+	i := min(int(v), len(heatRamp)-2)
+	return blend(heatRamp[i], heatRamp[i+1], v-float64(i))
+}
+
+// blend mixes f of b into a.
+func blend(a, b color.NRGBA, f float64) color.NRGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + f*(float64(b)-float64(a))))
+	}
+	return color.NRGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xFF}
+}
+
+// drawLine draws a line between two pixels, clipped to the image.
+func drawLine(img *image.NRGBA, x0, y0, x1, y1 int, c color.NRGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		if image.Pt(x0, y0).In(img.Rect) {
+			img.SetNRGBA(x0, y0, c)
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
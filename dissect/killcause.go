@@ -0,0 +1,61 @@
+package dissect
+
+import "math"
+
+// KillCause is what a Kill, DBNO or Death is attributed to: the weapon slot
+// fired, or Suicide and BleedOut from the kill feed.
+//
+// The kill packet does not name the weapon: of its 15 type bytes only the
+// kill type (byte 6) is known. Weapon slots are instead attributed from the
+// last ammo decrease of the attacker within killCauseWindow, tracked per ammo
+// entity in loadout.go. So the weapon itself is not known, and kills without
+// a shot fired just before them, e.g. by explosives, gadgets, melee or fall
+// damage, are Unknown.
+type KillCause string
+
+const (
+	KillCausePrimary   KillCause = "Primary"
+	KillCauseSecondary KillCause = "Secondary"
+	KillCauseAbility   KillCause = "Ability"  // operator ability launcher (e.g. Hibana X-KAIROS)
+	KillCauseSuicide   KillCause = "Suicide"  // the attacker is the target
+	KillCauseBleedOut  KillCause = "BleedOut" // a downed player died without an attacker
+	KillCauseUnknown   KillCause = "Unknown"  // no shot was seen, or there is no attacker
+)
+
+// killCauseWindow is how many seconds a shot may precede a kill and still be credited with it.
+const killCauseWindow = 2
+
+// shot is the last ammo decrease seen for a player.
+type shot struct {
+	entType       entityType
+	timeInSeconds float64
+}
+
+// recordShot remembers a decrease of the magazine of entity key as a shot by the player
+// and returns the number of rounds fired.
+func (r *Reader) recordShot(key uint32, username string, entType entityType, magazineAmmo int) int {
+	previous, seen := r.ammoMagazines[key]
+	r.ammoMagazines[key] = magazineAmmo
+	if !seen || magazineAmmo >= previous || len(username) == 0 {
+		return 0
+	}
+	r.lastShots[username] = shot{entType, r.time}
+	return previous - magazineAmmo
+}
+
+// weaponCause attributes a DBNO or kill by username to the weapon it last fired
+// within killCauseWindow. It returns KillCauseUnknown if it fired none.
+func (r *Reader) weaponCause(username string) KillCause {
+	s, ok := r.lastShots[username]
+	if !ok || math.Abs(s.timeInSeconds-r.time) > killCauseWindow {
+		return KillCauseUnknown
+	}
+	switch s.entType {
+	case entityTypeSecondary:
+		return KillCauseSecondary
+	case entityTypeAbility:
+		return KillCauseAbility
+	default:
+		return KillCausePrimary
+	}
+}
@@ -0,0 +1,286 @@
+package dissect
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Packet identifies a packet type with a registered layout.
+type Packet string
+
+const (
+	PacketPlayer            Packet = "player"
+	PacketAtkOpSwap         Packet = "atkOpSwap"
+	PacketSpawn             Packet = "spawn"
+	PacketTime              Packet = "time"
+	PacketY7Time            Packet = "y7Time"
+	PacketMatchFeedback     Packet = "matchFeedback"
+	PacketDefuserTimer      Packet = "defuserTimer"
+	PacketScoreboardScore   Packet = "scoreboardScore"
+	PacketScoreboardAssists Packet = "scoreboardAssists"
+	PacketScoreboardKills   Packet = "scoreboardKills"
+	PacketAmmo              Packet = "ammo"
+	PacketPosition          Packet = "position"
+)
+
+// Pattern is a byte sequence searched for in the decompressed replay.
+// It is encoded as hex text in JSON.
+type Pattern []byte
+
+func (p Pattern) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(hex.EncodeToString(p))), nil
+}
+
+func (p *Pattern) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(strings.ReplaceAll(string(text), " ", ""))
+	if err != nil {
+		return err
+	}
+	*p = b
+	return nil
+}
+
+// PacketLayout describes where the fields of a packet are found.
+// Offsets are byte counts skipped before reading the named field,
+// Indicators are patterns sought within the packet and
+// Flags toggle optional parts of the packet.
+type PacketLayout struct {
+	Marker     Pattern            `json:"marker,omitempty"` // nil disables the listener
+	Offsets    map[string]int     `json:"offsets,omitempty"`
+	Indicators map[string]Pattern `json:"indicators,omitempty"`
+	Flags      map[string]bool    `json:"flags,omitempty"`
+}
+
+// VersionedLayout is a PacketLayout used by replays with a CodeVersion of at least Since,
+// until the next layout registered for the same packet.
+type VersionedLayout struct {
+	Packet Packet       `json:"packet"`
+	Since  int          `json:"since"`
+	Layout PacketLayout `json:"layout"`
+}
+
+// with returns a copy of l with the given offsets and indicators added or replaced.
+func (l PacketLayout) with(offsets map[string]int, indicators map[string]Pattern) PacketLayout {
+	c := PacketLayout{
+		Marker:     l.Marker,
+		Offsets:    make(map[string]int),
+		Indicators: make(map[string]Pattern),
+		Flags:      make(map[string]bool),
+	}
+	for k, v := range l.Offsets {
+		c.Offsets[k] = v
+	}
+	for k, v := range offsets {
+		c.Offsets[k] = v
+	}
+	for k, v := range l.Indicators {
+		c.Indicators[k] = v
+	}
+	for k, v := range indicators {
+		c.Indicators[k] = v
+	}
+	for k, v := range l.Flags {
+		c.Flags[k] = v
+	}
+	return c
+}
+
+var playerLayout = PacketLayout{
+	Marker: Pattern{0x22, 0x07, 0x94, 0x9B, 0xDC},
+	Offsets: map[string]int{
+		"spawnValid":      10,
+		"profileIDSuffix": 5, // 22eed445c8
+	},
+	Indicators: map[string]Pattern{
+		"id":        {0xE6, 0xF9, 0x7D, 0x86},
+		"operator":  {0x22, 0xA9, 0x26, 0x0B, 0xE4},
+		"spawn":     {0xAF, 0x98, 0x99, 0xCA},
+		"profileID": {0x8A, 0x50, 0x9B, 0xD0},
+	},
+}
+
+var y7PlayerLayout = playerLayout.with(nil, map[string]Pattern{
+	"id": {0x33, 0xD8, 0x3D, 0x4F, 0x23},
+})
+
+var y7S4PlayerLayout = y7PlayerLayout.with(map[string]int{
+	// Sometimes, 0x40, 0xF2, 0x15, 0x04 is sent twice.
+	// The byte after swapCheck is 0x9D for the duplicate.
+	"swapCheck": 8,
+}, map[string]Pattern{
+	"operator": {0x40, 0xF2, 0x15, 0x04},
+})
+
+var atkOpSwapLayout = PacketLayout{
+	Marker:  Pattern{0x22, 0xA9, 0x26, 0x0B, 0xE4},
+	Offsets: map[string]int{"playerID": 5},
+}
+
+var matchFeedbackLayout = PacketLayout{
+	Marker:     Pattern{0x59, 0x34, 0xE5, 0x8B, 0x04},
+	Offsets:    map[string]int{"header": 1},
+	Indicators: map[string]Pattern{"activity": activity2},
+	Flags:      map[string]bool{"messages": true},
+}
+
+var defaultLayouts = []VersionedLayout{
+	{PacketPlayer, 0, playerLayout},
+	{PacketPlayer, Y7S2 + 1, y7PlayerLayout},
+	{PacketPlayer, Y7S4, y7S4PlayerLayout},
+	// ui id used by atk op swaps after the caster view overhaul
+	{PacketPlayer, Y9S3, y7S4PlayerLayout.with(
+		map[string]int{"uiID": 13},
+		map[string]Pattern{"uiID": {0x38, 0xDF, 0xEE, 0x88}},
+	)},
+	{PacketAtkOpSwap, 0, atkOpSwapLayout},
+	{PacketAtkOpSwap, Y9S3, PacketLayout{
+		Marker:  atkOpSwapLayout.Marker,
+		Offsets: map[string]int{"uiID": 402},
+	}},
+	{PacketSpawn, 0, PacketLayout{
+		Marker:  Pattern{0xAF, 0x98, 0x99, 0xCA},
+		Offsets: map[string]int{"sitePattern": 150},
+	}},
+	{PacketY7Time, 0, PacketLayout{Marker: Pattern{0x1E, 0xF1, 0x11, 0xAB}}},
+	{PacketY7Time, Y8S1, PacketLayout{}},
+	{PacketTime, 0, PacketLayout{}},
+	{PacketTime, Y8S1, PacketLayout{Marker: Pattern{0x1F, 0x07, 0xEF, 0xC9}}},
+	{PacketMatchFeedback, 0, matchFeedbackLayout},
+	// TODO: Y9S1 may have removed or modified other match feedback options
+	{PacketMatchFeedback, Y9S1, PacketLayout{
+		Marker:  matchFeedbackLayout.Marker,
+		Offsets: map[string]int{"valid": 9, "header": 24},
+	}},
+	{PacketMatchFeedback, Y9S1Update3, PacketLayout{
+		Marker:  matchFeedbackLayout.Marker,
+		Offsets: map[string]int{"header": 38},
+	}},
+	{PacketDefuserTimer, 0, PacketLayout{
+		Marker:  Pattern{0x22, 0xA9, 0xC8, 0x58, 0xD9},
+		Offsets: map[string]int{"playerID": 34},
+	}},
+	// Y10S4 changed packet structure - player DissectID is no longer included
+	{PacketDefuserTimer, Y10S4, PacketLayout{Marker: Pattern{0x22, 0xA9, 0xC8, 0x58, 0xD9}}},
+	{PacketScoreboardScore, 0, PacketLayout{
+		Marker:  Pattern{0xEC, 0xDA, 0x4F, 0x80},
+		Offsets: map[string]int{"playerID": 13},
+	}},
+	{PacketScoreboardAssists, 0, PacketLayout{
+		Marker:  Pattern{0x4D, 0x73, 0x7F, 0x9E},
+		Offsets: map[string]int{"playerID": 30},
+	}},
+	{PacketScoreboardKills, 0, PacketLayout{
+		Marker:  Pattern{0x1C, 0xD2, 0xB1, 0x9D},
+		Offsets: map[string]int{"playerID": 30},
+	}},
+	// Tracks primary/secondary weapon ammo and operator ability charges per player
+	{PacketAmmo, 0, PacketLayout{Marker: Pattern{0x77, 0xCA, 0x96, 0xDE}}},
+	// Player positions - only processed if TrackMovement is enabled
+	{PacketPosition, 0, PacketLayout{Marker: Pattern{0x00, 0x00, 0x60, 0x73, 0x85, 0xfe}}},
+}
+
+var layouts = struct {
+	sync.RWMutex
+	m map[Packet][]VersionedLayout // sorted by Since
+}{m: make(map[Packet][]VersionedLayout)}
+
+func init() {
+	for _, l := range defaultLayouts {
+		RegisterLayout(l.Packet, l.Since, l.Layout)
+	}
+}
+
+// RegisterLayout registers the layout of packet p for replays with a CodeVersion
+// of at least since, replacing any layout registered with the same since.
+// Readers created afterward use the new layout.
+func RegisterLayout(p Packet, since int, l PacketLayout) {
+	layouts.Lock()
+	defer layouts.Unlock()
+	entries := layouts.m[p]
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Since >= since
+	})
+	entry := VersionedLayout{Packet: p, Since: since, Layout: l}
+	if i < len(entries) && entries[i].Since == since {
+		entries[i] = entry
+		return
+	}
+	entries = append(entries, VersionedLayout{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	layouts.m[p] = entries
+}
+
+// LayoutFor returns the layout of packet p used by replays with the given CodeVersion.
+func LayoutFor(p Packet, codeVersion int) (PacketLayout, bool) {
+	layouts.RLock()
+	defer layouts.RUnlock()
+	entries := layouts.m[p]
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Since > codeVersion
+	})
+	if i == 0 {
+		return PacketLayout{}, false
+	}
+	return entries[i-1].Layout, true
+}
+
+// Layouts returns every registered layout, ordered by packet and version.
+func Layouts() []VersionedLayout {
+	layouts.RLock()
+	defer layouts.RUnlock()
+	all := make([]VersionedLayout, 0)
+	for _, entries := range layouts.m {
+		all = append(all, entries...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Packet != all[j].Packet {
+			return all[i].Packet < all[j].Packet
+		}
+		return all[i].Since < all[j].Since
+	})
+	return all
+}
+
+// LoadLayouts registers the layouts of a JSON array of VersionedLayout,
+// e.g. to support a new season without recompiling.
+func LoadLayouts(in io.Reader) error {
+	var entries []VersionedLayout
+	if err := json.NewDecoder(in).Decode(&entries); err != nil {
+		return err
+	}
+	for _, l := range entries {
+		if l.Packet == "" {
+			return fmt.Errorf("layout since %d: missing packet", l.Since)
+		}
+	}
+	for _, l := range entries {
+		RegisterLayout(l.Packet, l.Since, l.Layout)
+	}
+	return nil
+}
+
+// layout returns the layout of packet p for the replay being read.
+func (r *Reader) layout(p Packet) PacketLayout {
+	if r.layouts == nil {
+		r.layouts = make(map[Packet]PacketLayout)
+	}
+	l, ok := r.layouts[p]
+	if !ok {
+		l, _ = LayoutFor(p, r.Header.CodeVersion)
+		r.layouts[p] = l
+	}
+	return l
+}
+
+// listenLayout registers callback for the marker of packet p, if it has one.
+func (r *Reader) listenLayout(p Packet, callback func(r *Reader) error) {
+	if l := r.layout(p); len(l.Marker) > 0 {
+		r.Listen(l.Marker, callback)
+	}
+}
@@ -57,7 +57,7 @@ type PlayerLoadout struct {
 
 // ammoEntityEntry tracks first-appearance data for each unique ammo entity.
 type ammoEntityEntry struct {
-	firstOffset int        // byte offset of first 77CA96DE marker for this entity
+	firstOffset int        // absolute byte offset of first 77CA96DE marker for this entity
 	entType     entityType // primary, secondary, or ability
 	playerIdx   int        // mapped player index
 }
@@ -149,6 +149,13 @@ func readAmmo(r *Reader) error {
 	username := ""
 	if playerIdx >= 0 && playerIdx < len(r.Header.Players) {
 		username = r.Header.Players[playerIdx].Username
+		key := binary.LittleEndian.Uint32(entityID)
+		fired := r.recordShot(key, username, entType, int(magazineAmmo))
+		if fired > 0 && entType == entityTypeAbility {
+			if err := r.deployAbility(playerIdx, key, fired); err != nil {
+				return err
+			}
+		}
 	}
 
 	update := AmmoUpdate{
@@ -216,6 +223,9 @@ func readAmmo(r *Reader) error {
 
 	if len(username) > 0 {
 		r.AmmoUpdates = append(r.AmmoUpdates, update)
+		if err := r.emit(AmmoEvent{update}); err != nil {
+			return err
+		}
 	}
 
 	log.Debug().
@@ -234,7 +244,7 @@ func readAmmo(r *Reader) error {
 
 // extractAmmoEntityID reads the 4-byte entity ID from before the 77CA96DE pattern.
 func (r *Reader) extractAmmoEntityID() []byte {
-	start := r.ammoLastPatternOffset - 12
+	start := r.ammoLastPatternOffset - r.discarded - 12
 	if start < 0 || start+4 > len(r.b) {
 		return nil
 	}
@@ -378,6 +388,6 @@ func (r *Reader) GetAmmoTimeline(username string) []AmmoUpdate {
 
 // wrapAmmoReader bookmarks the pattern offset before readAmmo processes the data.
 func wrapAmmoReader(r *Reader) error {
-	r.ammoLastPatternOffset = r.offset
+	r.ammoLastPatternOffset = r.position()
 	return readAmmo(r)
 }
@@ -2,22 +2,43 @@ package dissect
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"os"
 	"path"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/xuri/excelize/v2"
 )
 
 type MatchReader struct {
-	Root   *os.File
-	paths  []string
-	rounds []*Reader
+	Root *os.File
+	// Stream decompresses each round incrementally to bound memory usage (see NewStreamReader).
+	Stream bool
+	// OnProgress optionally reports Read progress, including completed rounds.
+	OnProgress func(Progress)
+	// Lenient skips packets whose listener fails instead of aborting the round.
+	Lenient bool
+	// Retain keeps each round's decompressed replay after Read for Reader.Rescan.
+	Retain bool
+	// Workers limits the number of rounds Read parses concurrently.
+	// Zero or 1 reads rounds sequentially. Above 1, Listen callbacks and
+	// Subscribe handlers run concurrently for different rounds.
+	Workers int
+	// TrackMovement enables movement tracking of each round, sampling every
+	// MovementSampleRate-th position (see Reader.EnableMovementTracking).
+	TrackMovement      bool
+	MovementSampleRate int
+	paths              []string
+	rounds             []*Reader
+	mu                 sync.Mutex // guards rounds and OnProgress calls while reading concurrently
 
 	queries   [][]byte
 	listeners [][]func(r *Reader) error
@@ -37,13 +58,13 @@ func NewMatchReader(in *os.File) (m *MatchReader, err error) {
 }
 
 // Listen registers a callback to be run during round Read whenever
-// the pattern is found.
+// the pattern is found. With Workers above 1, callbacks for different
+// rounds run concurrently.
 func (m *MatchReader) Listen(pattern []byte, callback func(r *Reader) error) {
-	var i int
-	for i = 0; i < len(m.queries); i++ {
+	for i := 0; i < len(m.queries); i++ {
 		if bytes.Equal(m.queries[i], pattern) {
 			m.listeners[i] = append(m.listeners[i], callback)
-			break
+			return
 		}
 	}
 	m.queries = append(m.queries, pattern)
@@ -51,10 +72,14 @@ func (m *MatchReader) Listen(pattern []byte, callback func(r *Reader) error) {
 }
 
 func (m *MatchReader) read(i int) error {
+	return m.readContext(context.Background(), i)
+}
+
+func (m *MatchReader) readContext(ctx context.Context, i int) error {
 	if i < 0 || i >= len(m.paths) {
 		return ErrInvalidFile
 	}
-	if m.rounds[i] != nil {
+	if m.round(i) != nil {
 		return nil
 	}
 	f, err := os.Open(m.paths[i])
@@ -62,26 +87,102 @@ func (m *MatchReader) read(i int) error {
 		return err
 	}
 	defer f.Close()
-	r, err := NewReader(f)
+	open := NewReader
+	if m.Stream {
+		open = NewStreamReader
+	}
+	r, err := open(f)
 	if err != nil {
 		return err
 	}
-	m.rounds[i] = r
-	for i = 0; i < len(m.queries); i++ {
-		for _, listener := range m.listeners[i] {
-			r.Listen(m.queries[i], listener)
+	r.Lenient = m.Lenient
+	r.Retain = m.Retain
+	if m.TrackMovement {
+		r.EnableMovementTracking(m.MovementSampleRate)
+	}
+	for j := 0; j < len(m.queries); j++ {
+		for _, listener := range m.listeners[j] {
+			r.Listen(m.queries[j], listener)
 		}
 	}
-	return r.Read()
+	if m.OnProgress != nil {
+		r.OnProgress = func(p Progress) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			p.RoundsCompleted = m.roundsCompleted()
+			p.TotalRounds = m.NumRounds()
+			m.OnProgress(p)
+		}
+	}
+	err = r.ReadContext(ctx)
+	if ctx.Err() == nil {
+		m.mu.Lock()
+		m.rounds[i] = r
+		m.mu.Unlock()
+	}
+	return err
+}
+
+func (m *MatchReader) round(i int) *Reader {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rounds[i]
 }
 
 func (m *MatchReader) Read() error {
+	return m.ReadContext(context.Background())
+}
+
+// ReadContext is like Read, but stops with the context error once ctx is done.
+// Rounds are parsed by up to Workers goroutines. Every round is attempted;
+// the failures are joined as *RoundError values in round order.
+func (m *MatchReader) ReadContext(ctx context.Context) error {
+	workers := max(m.Workers, 1)
+	errs := make([]error, len(m.paths))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
 	for i := range m.paths {
-		if err := m.read(i); err != nil {
-			return err
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			break
 		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := m.readContext(ctx, i); !Ok(err) {
+				errs[i] = &RoundError{Round: i, Path: m.paths[i], Err: err}
+				return
+			}
+			if m.OnProgress != nil {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				m.OnProgress(Progress{
+					RoundsCompleted: m.roundsCompleted(),
+					TotalRounds:     m.NumRounds(),
+				})
+			}
+		}(i)
 	}
-	return nil
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// roundsCompleted returns the number of rounds read so far.
+// The caller must hold m.mu while reading concurrently.
+func (m *MatchReader) roundsCompleted() int {
+	n := 0
+	for _, r := range m.rounds {
+		if r != nil {
+			n++
+		}
+	}
+	return n
 }
 
 func (m *MatchReader) FirstRound() (r *Reader, err error) {
@@ -93,18 +194,61 @@ func (m *MatchReader) LastRound() (r *Reader, err error) {
 }
 
 func (m *MatchReader) RoundAt(i int) (r *Reader, err error) {
-	if m.rounds[i] == nil {
+	if m.round(i) == nil {
 		if err := m.read(i); err != nil {
 			return nil, err
 		}
 	}
-	return m.rounds[i], nil
+	return m.round(i), nil
 }
 
 func (m *MatchReader) NumRounds() int {
 	return len(m.paths)
 }
 
+// SnapshotAt returns the state of every tracked player in the round the
+// elapsed seconds after its first clock tick. The round is read if needed;
+// enable TrackMovement before reading. See Timeline.At.
+func (m *MatchReader) SnapshotAt(round int, elapsed float64) (Snapshot, error) {
+	if round < 0 || round >= m.NumRounds() {
+		return Snapshot{}, ErrInvalidFile
+	}
+	r, err := m.RoundAt(round)
+	if !Ok(err) {
+		return Snapshot{}, err
+	}
+	return r.SnapshotAt(elapsed), nil
+}
+
+// Snapshots returns an iterator over the round indexes and snapshots of
+// every round read so far, tickRate times per second. See Timeline.Snapshots.
+func (m *MatchReader) Snapshots(tickRate float64) iter.Seq2[int, Snapshot] {
+	return func(yield func(int, Snapshot) bool) {
+		for i := range m.rounds {
+			r := m.round(i)
+			if r == nil {
+				continue
+			}
+			for s := range r.Snapshots(tickRate) {
+				if !yield(i, s) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Diagnostics returns the diagnostics of each round read so far, indexed like RoundAt.
+func (m *MatchReader) Diagnostics() [][]Diagnostic {
+	diagnostics := make([][]Diagnostic, len(m.rounds))
+	for i, r := range m.rounds {
+		if r != nil {
+			diagnostics[i] = r.Diagnostics
+		}
+	}
+	return diagnostics
+}
+
 func (m *MatchReader) WriteExcel(out io.Writer) error {
 	f := excelize.NewFile()
 	defer f.Close()
@@ -140,6 +284,7 @@ func (m *MatchReader) WriteExcel(out io.Writer) error {
 		c.Down(1).Str("Player")
 		c.Right(1).Str("Team Index")
 		c.Right(1).Str("Kills")
+		c.Right(1).Str("Team Kills")
 		c.Right(1).Str("Died")
 		c.Right(1).Str("Assists")
 		c.Right(1).Str("Hs%")
@@ -153,9 +298,10 @@ func (m *MatchReader) WriteExcel(out io.Writer) error {
 		}
 
 		for _, s := range r.PlayerStats() {
-			c.Down(1).Left(8).Str(s.Username)
+			c.Down(1).Left(9).Str(s.Username)
 			c.Right(1).Int(s.TeamIndex)
 			c.Right(1).Int(s.Kills)
+			c.Right(1).Int(s.TeamKills)
 			c.Right(1).Bool(s.Died)
 			c.Right(1).Int(s.Assists)
 			c.Right(1).Float(s.HeadshotPercentage, 3)
@@ -165,7 +311,7 @@ func (m *MatchReader) WriteExcel(out io.Writer) error {
 			log.Debug().Interface("round_player_stats", s).Send()
 		}
 
-		c.Down(2).Left(8).Heading("Round info")
+		c.Down(2).Left(9).Heading("Round info")
 		c.Down(1).Str("Name")
 		c.Right(1).Str("Value")
 		c.Right(1).Str("Time")
@@ -248,6 +394,7 @@ func (m *MatchReader) WriteExcel(out io.Writer) error {
 	c.Right(1).Str("Team Index")
 	c.Right(1).Str("Rounds")
 	c.Right(1).Str("Kills")
+	c.Right(1).Str("Team Kills")
 	c.Right(1).Str("Deaths")
 	c.Right(1).Str("Assists")
 	c.Right(1).Str("Hs%")
@@ -258,6 +405,7 @@ func (m *MatchReader) WriteExcel(out io.Writer) error {
 		c.Right(1).Int(s.TeamIndex)
 		c.Right(1).Int(s.Rounds)
 		c.Right(1).Int(s.Kills)
+		c.Right(1).Int(s.TeamKills)
 		c.Right(1).Int(s.Deaths)
 		c.Right(1).Int(s.Assists)
 		c.Right(1).Float(s.HeadshotPercentage, 3)
@@ -265,11 +413,84 @@ func (m *MatchReader) WriteExcel(out io.Writer) error {
 		log.Debug().Interface("match_player_stats", s).Send()
 	}
 
+	if err := m.writeMovementSheet(f, c); err != nil {
+		return err
+	}
+
 	f.SetActiveSheet(first)
 
 	return f.Write(out)
 }
 
+// writeMovementSheet adds a Movement sheet with the movement metrics of
+// each player per round and for the match, if movement was tracked.
+func (m *MatchReader) writeMovementSheet(f *excelize.File, c *excelCompass) error {
+	stats := m.PlayerStats()
+	movement := make([]*MovementStats, 0)
+	for _, s := range stats {
+		if s.Movement != nil {
+			movement = append(movement, s.Movement)
+		}
+	}
+	if len(movement) == 0 {
+		return nil
+	}
+	maps := make([]string, 0, len(m.rounds))
+	for _, r := range m.rounds {
+		maps = append(maps, r.Header.Map.String())
+	}
+	floors := floorNames(maps, movement)
+	if _, err := f.NewSheet("Movement"); err != nil {
+		return err
+	}
+	c.Sheet("Movement")
+	header := func() {
+		c.Down(1).Str("Player")
+		c.Right(1).Str("Distance (m)")
+		c.Right(1).Str("Avg Speed (m/s)")
+		c.Right(1).Str("Peak Speed (m/s)")
+		c.Right(1).Str("Stationary (s)")
+		c.Right(1).Str("Outside (s)")
+		for _, floor := range floors {
+			c.Right(1).Str(floor + " (s)")
+		}
+		c.Left(5 + len(floors))
+	}
+	row := func(username string, s *MovementStats) {
+		c.Down(1).Str(username)
+		c.Right(1).Float(s.Distance, 1)
+		c.Right(1).Float(s.AverageSpeed, 2)
+		c.Right(1).Float(s.PeakSpeed, 2)
+		c.Right(1).Float(s.StationaryTime, 1)
+		c.Right(1)
+		if s.OutsideTime != nil {
+			c.Float(*s.OutsideTime, 1)
+		}
+		for _, floor := range floors {
+			c.Right(1).Float(s.FloorTime[floor], 1)
+		}
+		c.Left(5 + len(floors))
+	}
+
+	c.Heading("Match")
+	header()
+	for _, s := range stats {
+		if s.Movement != nil {
+			row(s.Username, s.Movement)
+		}
+	}
+	for i, r := range m.rounds {
+		c.Down(2).Heading(fmt.Sprintf("Round %d", i+1))
+		header()
+		for _, s := range r.PlayerStats() {
+			if s.Movement != nil {
+				row(s.Username, s.Movement)
+			}
+		}
+	}
+	return nil
+}
+
 func (m *MatchReader) WriteJSON(out io.Writer) error {
 	encoder := json.NewEncoder(out)
 	return encoder.Encode(m.Data())
@@ -280,6 +501,7 @@ func (m *MatchReader) Data() any {
 		Header
 		MatchFeedback []MatchUpdate      `json:"matchFeedback"`
 		PlayerStats   []PlayerRoundStats `json:"stats"`
+		Rooms         []PlayerRooms      `json:"rooms,omitempty"`
 	}
 	type output struct {
 		Rounds      []round            `json:"rounds"`
@@ -291,6 +513,7 @@ func (m *MatchReader) Data() any {
 			Header:        r.Header,
 			MatchFeedback: r.MatchFeedback,
 			PlayerStats:   r.PlayerStats(),
+			Rooms:         r.RoomTimeline(),
 		})
 	}
 	return output{
@@ -20,11 +20,21 @@ func _() {
 	_ = x[Battleye-9]
 	_ = x[PlayerLeave-10]
 	_ = x[Other-11]
+	_ = x[Revive-12]
+	_ = x[BleedOut-13]
+	_ = x[RoundEnd-14]
+	_ = x[AreaContested-15]
+	_ = x[AreaSecured-16]
+	_ = x[HostagePickup-17]
+	_ = x[HostageDrop-18]
+	_ = x[HostageExtracted-19]
+	_ = x[HostageDBNO-20]
+	_ = x[HostageKill-21]
 }
 
-const _MatchUpdateType_name = "KillDeathDBNODefuserPlantStartDefuserPlantCompleteDefuserDisableStartDefuserDisableCompleteLocateObjectiveOperatorSwapBattleyePlayerLeaveOther"
+const _MatchUpdateType_name = "KillDeathDBNODefuserPlantStartDefuserPlantCompleteDefuserDisableStartDefuserDisableCompleteLocateObjectiveOperatorSwapBattleyePlayerLeaveOtherReviveBleedOutRoundEndAreaContestedAreaSecuredHostagePickupHostageDropHostageExtractedHostageDBNOHostageKill"
 
-var _MatchUpdateType_index = [...]uint8{0, 4, 9, 13, 30, 50, 69, 91, 106, 118, 126, 137, 142}
+var _MatchUpdateType_index = [...]uint16{0, 4, 9, 13, 30, 50, 69, 91, 106, 118, 126, 137, 142, 148, 156, 164, 177, 188, 201, 212, 228, 239, 250}
 
 func (i MatchUpdateType) String() string {
 	idx := int(i) - 0
@@ -9,11 +9,13 @@ import (
 
 // PlayerPosition represents a player's position at a specific time.
 type PlayerPosition struct {
-	TimeInSeconds float64 `json:"timeInSeconds"`
-	X             float32 `json:"x"`
-	Y             float32 `json:"y"`
-	Z             float32 `json:"z"`
-	Yaw           float32 `json:"yaw,omitempty"` // Rotation in degrees (0-360)
+	TimeInSeconds  float64 `json:"timeInSeconds"`  // round clock, as in MatchUpdate
+	ElapsedSeconds float64 `json:"elapsedSeconds"` // seconds since the first clock tick of the replay
+	X              float32 `json:"x"`
+	Y              float32 `json:"y"`
+	Z              float32 `json:"z"`
+	Yaw            float32 `json:"yaw,omitempty"`  // Rotation in degrees (0-360)
+	Room           string  `json:"room,omitempty"` // see GetRoomAtPosition
 }
 
 // PlayerMovement tracks all positions for a single player throughout a round.
@@ -23,11 +25,13 @@ type PlayerMovement struct {
 	Team      string           `json:"team"` // "Attack" or "Defense"
 	Loadout   *PlayerLoadout   `json:"loadout,omitempty"` // Initial loadout (ammo capacities)
 	Positions []PlayerPosition `json:"positions"`
+	packets   []int            // packet numbers of Positions, for entity owners
 }
 
 // rawPosition stores position packets before track assignment
 type rawPosition struct {
 	packetNum int
+	time      float64 // round clock when the packet was read
 	entityID  uint32  // 4-byte entity ID from before the marker
 	playerID  uint32  // Player ID from packet payload (maps to header index via playerID-5)
 	x, y, z   float32
@@ -79,7 +83,7 @@ var movementMarkers = [][]byte{
 //     - Type 0x01/0x02: at offset +4 (bytes 18-21 from marker)
 //   - Player ID maps to header index via: playerIndex = playerID - 5
 func readPlayerPosition(r *Reader) error {
-	if !r.TrackMovement {
+	if !r.TrackMovement && !r.subscribed(PositionEvent{}) {
 		return nil
 	}
 
@@ -116,6 +120,9 @@ func readPlayerPosition(r *Reader) error {
 	// 0x03 is the full packet (player ID at post-coord +20, includes quaternion)
 	// Experimentally capture other types when enabled
 	if typeSecond != 0x01 && typeSecond != 0x02 && typeSecond != 0x03 {
+		if r.TrackMovement && typeFirst >= 0xB0 {
+			r.peekEntity(packetNum, entityID, typeFirst, typeSecond)
+		}
 		if r.ExperimentalTypes && typeFirst >= 0xB0 {
 			captureExperimentalPacket(r, typeFirst, typeSecond, packetNum)
 		}
@@ -179,21 +186,44 @@ func readPlayerPosition(r *Reader) error {
 		}
 	}
 
-	if r.rawPositions == nil {
-		r.rawPositions = make([]rawPosition, 0, 50000)
+	// Packets without a header player ID are non-player entities
+	if !r.isPlayerID(playerID) {
+		if r.TrackMovement {
+			r.trackEntity(packetNum, entityID, typeFirst, typeSecond, x, y, z)
+		}
+	} else if r.TrackMovement {
+		if r.rawPositions == nil {
+			r.rawPositions = make([]rawPosition, 0, 50000)
+		}
+
+		r.rawPositions = append(r.rawPositions, rawPosition{
+			packetNum: packetNum,
+			time:      r.time,
+			entityID:  entityID,
+			playerID:  playerID,
+			x:         x,
+			y:         y,
+			z:         z,
+			yaw:       yaw,
+		})
 	}
 
-	r.rawPositions = append(r.rawPositions, rawPosition{
-		packetNum: packetNum,
-		entityID:  entityID,
-		playerID:  playerID,
-		x:         x,
-		y:         y,
-		z:         z,
-		yaw:       yaw,
-	})
+	// Remember where each player was last seen, for ability positions
+	if r.isPlayerID(playerID) {
+		r.lastPositions[int(playerID)-5] = PlayerPosition{TimeInSeconds: r.time, ElapsedSeconds: r.elapsed, X: x, Y: y, Z: z, Yaw: yaw}
+	}
 
-	return nil
+	return r.emit(PositionEvent{
+		PacketNum:     packetNum,
+		EntityID:      entityID,
+		PlayerID:      playerID,
+		X:             x,
+		Y:             y,
+		Z:             z,
+		Yaw:           yaw,
+		Time:          r.timeRaw,
+		TimeInSeconds: r.time,
+	})
 }
 
 // readFloat32LE reads a little-endian float32 from bytes
@@ -235,6 +265,7 @@ func isValidWorldCoord(f float32) bool {
 }
 
 // GetMovementData builds player movement tracks using pure spatial tracking.
+// The tracks are built once Read finishes and shared by later calls.
 //
 // The player ID field in movement packets (values 5-14) is unreliable for
 // per-position attribution -- 59% of map locations have positions tagged with
@@ -249,6 +280,14 @@ func isValidWorldCoord(f float32) bool {
 //  6. Assigns tracks to players using prep-phase movement (defenders move more)
 //     and player ID majority vote as a secondary hint
 func (r *Reader) GetMovementData() []PlayerMovement {
+	if r.movements != nil {
+		return r.movements
+	}
+	return r.newMovementData()
+}
+
+// newMovementData builds the player movement tracks, see GetMovementData.
+func (r *Reader) newMovementData() []PlayerMovement {
 	if len(r.rawPositions) == 0 {
 		return nil
 	}
@@ -259,37 +298,9 @@ func (r *Reader) GetMovementData() []PlayerMovement {
 	}
 
 	// --- Time estimation ---
-	var minPkt, maxPkt int = -1, -1
-	for _, pos := range r.rawPositions {
-		if minPkt < 0 || pos.packetNum < minPkt {
-			minPkt = pos.packetNum
-		}
-		if pos.packetNum > maxPkt {
-			maxPkt = pos.packetNum
-		}
-	}
-	pktRange := float64(maxPkt - minPkt)
-	if pktRange <= 0 {
-		pktRange = 1
-	}
-
-	var minCountdown float64 = 180
-	for _, event := range r.MatchFeedback {
-		if event.TimeInSeconds > 0 && event.TimeInSeconds < minCountdown {
-			minCountdown = event.TimeInSeconds
-		}
-	}
-	actionDuration := 180.0 - minCountdown
-	if actionDuration < 10 || math.IsNaN(actionDuration) || math.IsInf(actionDuration, 0) {
-		actionDuration = 180.0
-	}
-	totalTime := 45.0 + actionDuration
-	if totalTime <= 0 || math.IsNaN(totalTime) || math.IsInf(totalTime, 0) {
-		totalTime = 225.0
-	}
-	pktToTime := func(pkt int) float64 {
-		return (float64(pkt-minPkt) / pktRange) * totalTime
-	}
+	clock := r.newPacketClock()
+	pktToTime := clock.elapsed
+	mapName := r.Header.Map.String()
 
 	dist2D := func(x1, y1, x2, y2 float32) float32 {
 		dx := x1 - x2
@@ -389,8 +400,7 @@ func (r *Reader) GetMovementData() []PlayerMovement {
 
 	// --- Step 4: Assign tracks to players ---
 	// Use prep-phase movement to separate defenders (high movement) from attackers (low movement)
-	prepPktFraction := 45.0 / totalTime
-	prepPhaseEndPkt := minPkt + int(pktRange*prepPktFraction)
+	prepPhaseEndPkt := clock.actionStart()
 
 	type trackMeta struct {
 		track         *spatialTrack
@@ -446,11 +456,8 @@ func (r *Reader) GetMovementData() []PlayerMovement {
 	// Extract death times from match feedback
 	deathTimes := make(map[string]float64)
 	for _, ev := range r.MatchFeedback {
-		if ev.Type == Kill && ev.Target != "" {
-			deathTimes[ev.Target] = ev.TimeInSeconds
-		}
-		if ev.Type == Death {
-			deathTimes[ev.Username] = ev.TimeInSeconds
+		if victim, ok := ev.victim(); ok {
+			deathTimes[victim] = ev.TimeInSeconds
 		}
 	}
 
@@ -593,21 +600,21 @@ func (r *Reader) GetMovementData() []PlayerMovement {
 	for _, pairs := range [][]playerTrackPair{defPairs, atkPairs} {
 		for _, p := range pairs {
 			var positions []PlayerPosition
+			var packets []int
 			for _, pos := range p.track.track.positions {
-				t := pktToTime(pos.packetNum)
-				if math.IsNaN(t) || math.IsInf(t, 0) {
-					t = 0
-				}
+				packets = append(packets, pos.packetNum)
 				yaw := pos.yaw
 				if math.IsNaN(float64(yaw)) || math.IsInf(float64(yaw), 0) {
 					yaw = 0
 				}
 				positions = append(positions, PlayerPosition{
-					TimeInSeconds: t,
-					X:             pos.x,
-					Y:             pos.y,
-					Z:             pos.z,
-					Yaw:           yaw,
+					TimeInSeconds:  clock.roundTime(pos.packetNum),
+					ElapsedSeconds: pktToTime(pos.packetNum),
+					X:              pos.x,
+					Y:              pos.y,
+					Z:              pos.z,
+					Yaw:            yaw,
+					Room:           GetRoomAtPosition(mapName, pos.x, pos.y, pos.z),
 				})
 			}
 
@@ -617,6 +624,7 @@ func (r *Reader) GetMovementData() []PlayerMovement {
 				Team:      p.player.team,
 				Loadout:   p.player.loadout,
 				Positions: positions,
+				packets:   packets,
 			})
 		}
 	}
@@ -749,6 +757,7 @@ func (r *Reader) EnableMovementTracking(sampleRate int) {
 // RawPosition is an exported version of rawPosition for diagnostic tools
 type RawPosition struct {
 	PacketNum int
+	Time      float64 // round clock when the packet was read
 	EntityID  uint32
 	PlayerID  uint32 // Player ID from packet (maps to header index via playerID-5)
 	X, Y, Z   float32
@@ -791,6 +800,7 @@ func (r *Reader) GetRawPositions() []RawPosition {
 	for i, p := range r.rawPositions {
 		result[i] = RawPosition{
 			PacketNum: p.packetNum,
+			Time:      p.time,
 			EntityID:  p.entityID,
 			PlayerID:  p.playerID,
 			X:         p.x,
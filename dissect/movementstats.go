@@ -0,0 +1,140 @@
+package dissect
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// Movement metric thresholds.
+const (
+	maxPlayerSpeed  = 10.0 // m/s; faster segments are tracking jumps and are skipped
+	maxTrackingGap  = 2.0  // seconds; longer gaps between positions are not counted
+	stationarySpeed = 0.3  // m/s; slower segments count as stationary
+	speedWindow     = 0.5  // seconds of movement measured for the peak speed
+)
+
+// MovementStats are metrics of a player's movement derived from their
+// tracked positions. Times are in seconds and distances in meters.
+type MovementStats struct {
+	Distance       float64            `json:"distance"`
+	TrackedTime    float64            `json:"trackedTime"`         // time covered by the positions
+	AverageSpeed   float64            `json:"averageSpeed"`        // distance over tracked time
+	PeakSpeed      float64            `json:"peakSpeed"`           // fastest speedWindow of movement
+	FloorTime      map[string]float64 `json:"floorTime,omitempty"` // time per FloorAt floor
+	StationaryTime float64            `json:"stationaryTime"`
+	OutsideTime    *float64           `json:"outsideTime,omitempty"` // defenders on maps with complete room geometry only, see insideBuilding
+}
+
+// MovementStats returns the movement metrics of each tracked player by username.
+// It is empty unless movement tracking was enabled during Read.
+// The metrics are computed once Read finishes and shared by later calls.
+func (r *Reader) MovementStats() map[string]MovementStats {
+	if r.movementStats != nil {
+		return r.movementStats
+	}
+	return r.newMovementStats()
+}
+
+// newMovementStats computes the movement metrics of each tracked player by username.
+func (r *Reader) newMovementStats() map[string]MovementStats {
+	stats := make(map[string]MovementStats)
+	mapName := r.Header.Map.String()
+	for _, m := range r.GetMovementData() {
+		stats[m.Username] = movementStats(m.Positions, mapName, m.Team == "Defense")
+	}
+	return stats
+}
+
+// movementStats computes the metrics of positions ordered by ElapsedSeconds.
+func movementStats(positions []PlayerPosition, mapName string, defender bool) MovementStats {
+	s := MovementStats{FloorTime: make(map[string]float64)}
+	if g, ok := MapGeometryFor(mapName); ok && g.Complete && defender {
+		s.OutsideTime = new(float64)
+	}
+	window, windowTime := 0.0, 0.0
+	for i := 1; i < len(positions); i++ {
+		a, b := positions[i-1], positions[i]
+		dt := b.ElapsedSeconds - a.ElapsedSeconds
+		if dt <= 0 || dt > maxTrackingGap {
+			continue
+		}
+		d := math.Sqrt(float64((b.X-a.X)*(b.X-a.X) + (b.Y-a.Y)*(b.Y-a.Y) + (b.Z-a.Z)*(b.Z-a.Z)))
+		if d/dt > maxPlayerSpeed {
+			continue
+		}
+		s.Distance += d
+		s.TrackedTime += dt
+		s.FloorTime[FloorAt(mapName, a.Z)] += dt
+		if d/dt < stationarySpeed {
+			s.StationaryTime += dt
+		}
+		if inside, known := insideBuilding(mapName, a.X, a.Y, a.Z); known && !inside && s.OutsideTime != nil {
+			*s.OutsideTime += dt
+		}
+		window += d
+		windowTime += dt
+		if windowTime >= speedWindow {
+			s.PeakSpeed = math.Max(s.PeakSpeed, window/windowTime)
+			window, windowTime = 0, 0
+		}
+	}
+	if s.TrackedTime > 0 {
+		s.AverageSpeed = s.Distance / s.TrackedTime
+	}
+	if len(s.FloorTime) == 0 {
+		s.FloorTime = nil
+	}
+	return s
+}
+
+// add sums the metrics of another round into s.
+func (s *MovementStats) add(o MovementStats) {
+	s.Distance += o.Distance
+	s.TrackedTime += o.TrackedTime
+	s.PeakSpeed = math.Max(s.PeakSpeed, o.PeakSpeed)
+	s.StationaryTime += o.StationaryTime
+	if o.OutsideTime != nil {
+		if s.OutsideTime == nil {
+			s.OutsideTime = new(float64)
+		}
+		*s.OutsideTime += *o.OutsideTime
+	}
+	for floor, t := range o.FloorTime {
+		if s.FloorTime == nil {
+			s.FloorTime = make(map[string]float64)
+		}
+		s.FloorTime[floor] += t
+	}
+	s.AverageSpeed = 0
+	if s.TrackedTime > 0 {
+		s.AverageSpeed = s.Distance / s.TrackedTime
+	}
+}
+
+// floorNames returns the floors with time in the stats, from the bottom up
+// as in the floors of the maps, followed by any others by name.
+func floorNames(maps []string, stats []*MovementStats) []string {
+	seen := make(map[string]bool)
+	for _, s := range stats {
+		for floor := range s.FloorTime {
+			seen[floor] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for _, m := range maps {
+		for _, floor := range mapFloors(m) {
+			if seen[floor.Name] && !slices.Contains(names, floor.Name) {
+				names = append(names, floor.Name)
+			}
+		}
+	}
+	others := make([]string, 0)
+	for floor := range seen {
+		if !slices.Contains(names, floor) {
+			others = append(others, floor)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
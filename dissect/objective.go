@@ -0,0 +1,115 @@
+package dissect
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// hostageName is the kill feed name of the hostage.
+const hostageName = "Hostage"
+
+// objectiveMessages are the known formats of the objective match feedback
+// messages per game mode, "<username> " followed by the text.
+var objectiveMessages = map[GameMode]map[string]MatchUpdateType{
+	SecureArea: {
+		"contested the area": AreaContested,
+		"secured the area":   AreaSecured,
+	},
+	Hostage: {
+		"picked up the hostage": HostagePickup,
+		"dropped the hostage":   HostageDrop,
+		"extracted the hostage": HostageExtracted,
+	},
+}
+
+// objectiveMessage returns the objective update type and username of a match
+// feedback message in the Secure Area and Hostage game modes. Only messages of
+// a player in one of the objectiveMessages formats are matched.
+//
+// Only replays before Y9S1 carry messages, so later replays only get the
+// hostage DBNOs and kills of the kill feed.
+func (r *Reader) objectiveMessage(msg string) (MatchUpdateType, string, bool) {
+	username, text, ok := strings.Cut(msg, " ")
+	if !ok || r.PlayerIndexByUsername(username) < 0 {
+		return Other, "", false
+	}
+	t, ok := objectiveMessages[r.Header.GameMode][text]
+	return t, username, ok
+}
+
+// isObjective reports whether t is a Secure Area or Hostage objective update.
+func isObjective(t MatchUpdateType) bool {
+	return t >= AreaContested && t <= HostageKill
+}
+
+// readHostageHarm records a kill feed DBNO or kill of the hostage.
+func (r *Reader) readHostageHarm(attacker string, killType byte) error {
+	u := MatchUpdate{
+		Type:          HostageKill,
+		Username:      attacker,
+		Target:        hostageName,
+		Time:          r.timeRaw,
+		TimeInSeconds: r.time,
+	}
+	if killType == killTypeDBNO {
+		u.Type = HostageDBNO
+	}
+	if err := r.addFeedback(u); err != nil {
+		return err
+	}
+	log.Debug().Interface("match_update", u).Send()
+	return nil
+}
+
+// objectiveEnd decides the round from the Secure Area and Hostage objective
+// updates and reports whether it did.
+func (r *Reader) objectiveEnd() bool {
+	for i := len(r.MatchFeedback) - 1; i >= 0; i-- {
+		u := r.MatchFeedback[i]
+		winner := -1
+		var condition WinCondition
+		switch u.Type {
+		case AreaSecured:
+			if p := r.PlayerIndexByUsername(u.Username); p >= 0 {
+				winner = r.Header.Players[p].TeamIndex
+			}
+			condition = SecuredArea
+		case HostageExtracted:
+			winner = r.teamOfRole(Attack)
+			condition = ExtractedHostage
+		case HostageKill:
+			if p := r.PlayerIndexByUsername(u.Username); p >= 0 {
+				winner = r.Header.Players[p].TeamIndex ^ 1
+			}
+			condition = KilledHostage
+		default:
+			continue
+		}
+		if r.Header.CodeVersion >= Y9S4 {
+			// the header decides the winner
+			winner = 0
+			if r.Header.Teams[1].Won {
+				winner = 1
+			}
+		}
+		if winner < 0 || winner > 1 {
+			return false
+		}
+		r.Header.Teams[winner].Won = true
+		r.Header.Teams[winner^1].Won = false
+		r.Header.Teams[winner].WinCondition = condition
+		return true
+	}
+	return false
+}
+
+// teamOfRole returns the index of the team playing role, or -1.
+func (r *Reader) teamOfRole(role TeamRole) int {
+	for i, team := range r.Header.Teams {
+		if team.Role == role {
+			return i
+		}
+	}
+	return -1
+}
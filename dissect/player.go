@@ -2,18 +2,14 @@ package dissect
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 
 	"github.com/rs/zerolog/log"
 )
 
 func readPlayer(r *Reader) error {
-	idIndicator := []byte{0x33, 0xD8, 0x3D, 0x4F, 0x23}
-	if r.Header.CodeVersion <= Y7S2 {
-		idIndicator = []byte{0xE6, 0xF9, 0x7D, 0x86}
-	}
-	spawnIndicator := []byte{0xAF, 0x98, 0x99, 0xCA}
-	profileIDIndicator := []byte{0x8A, 0x50, 0x9B, 0xD0}
+	l := r.layout(PacketPlayer)
 	//unknownIndicator := []byte{0x22, 0xEE, 0xD4, 0x45, 0xC8, 0x08} // maybe player appearance?
 	r.playersRead++
 	defer func() {
@@ -25,26 +21,22 @@ func readPlayer(r *Reader) error {
 	if err != nil {
 		return err
 	}
-	if r.Header.CodeVersion >= Y7S4 {
-		if err := r.Seek([]byte{0x40, 0xF2, 0x15, 0x04}); err != nil {
-			return err
-		}
-		if err = r.Skip(8); err != nil {
+	if err := r.Seek(l.Indicators["operator"]); err != nil {
+		return err
+	}
+	if n, ok := l.Offsets["swapCheck"]; ok {
+		if err = r.Skip(n); err != nil {
 			return err
 		}
 		swap, err := r.Bytes(1)
 		if err != nil {
 			return err
 		}
-		// Sometimes, 0x40, 0xF2, 0x15, 0x04 is sent twice.
+		// Sometimes, the operator indicator is sent twice.
 		// Does not seem to be linked to role swap.
 		if swap[0] == 0x9D {
 			return nil
 		}
-	} else {
-		if err := r.Seek([]byte{0x22, 0xA9, 0x26, 0x0B, 0xE4}); err != nil {
-			return err
-		}
 	}
 	op, err := r.Uint64() // Op before atk role swaps
 	if err != nil {
@@ -60,16 +52,18 @@ func readPlayer(r *Reader) error {
 	}
 	if validPlayer[0] != 0x22 {
 		log.Warn().Uint64("op", op).Msg("strange invalid player located")
+		r.diagnose(SeverityWarning, fmt.Sprintf("invalid player %q located (op %d)", username, op))
 		return nil
 	}
-	if err := r.Seek(idIndicator); err != nil {
+	if err := r.Seek(l.Indicators["id"]); err != nil {
 		return err
 	}
 	id, err := r.Bytes(4)
 	if err != nil {
 		return err
 	}
-	if err := r.Seek(spawnIndicator); err != nil {
+	id = bytes.Clone(id) // outlives the streaming window
+	if err := r.Seek(l.Indicators["spawn"]); err != nil {
 		return err
 	}
 	spawn, err := r.String()
@@ -77,7 +71,7 @@ func readPlayer(r *Reader) error {
 		return err
 	}
 	if spawn == "" {
-		if err = r.Skip(10); err != nil {
+		if err = r.Skip(l.Offsets["spawnValid"]); err != nil {
 			return err
 		}
 		valid, err := r.Bytes(1)
@@ -95,11 +89,11 @@ func readPlayer(r *Reader) error {
 	// ui id (y9s3+?)
 	// there seems to be more to this, but its a quick fix for atk op swaps for now
 	var uiID uint64
-	if r.Header.CodeVersion >= Y9S3 {
-		if err = r.Seek([]byte{0x38, 0xDF, 0xEE, 0x88}); err != nil {
+	if uiIndicator, ok := l.Indicators["uiID"]; ok {
+		if err = r.Seek(uiIndicator); err != nil {
 			return err
 		}
-		if err = r.Skip(13); err != nil {
+		if err = r.Skip(l.Offsets["uiID"]); err != nil {
 			return err
 		}
 		if uiID, err = r.Uint64(); err != nil {
@@ -110,16 +104,17 @@ func readPlayer(r *Reader) error {
 	profileID := ""
 	var unknownId uint64
 	if len(r.Header.RecordingProfileID) > 0 {
-		if err = r.Seek(profileIDIndicator); err != nil {
+		if err = r.Seek(l.Indicators["profileID"]); err != nil {
 			return err
 		}
 		profileID, err = r.String()
 		if err != nil {
 			return err
 		}
-		if err = r.Skip(5); err != nil { // 22eed445c8
+		if err = r.Skip(l.Offsets["profileIDSuffix"]); err != nil {
 			return err
 		}
+		r.playerIDOffsets = append(r.playerIDOffsets, r.position()+1) // after the size byte
 		unknownId, err = r.Uint64()
 		if err != nil {
 			return err
@@ -176,9 +171,10 @@ func readAtkOpSwap(r *Reader) error {
 		return err
 	}
 	o := Operator(op)
+	l := r.layout(PacketAtkOpSwap)
 	// before Y9S3 caster view overhaul
-	if r.Header.CodeVersion < Y9S3 {
-		if err = r.Skip(5); err != nil {
+	if n, ok := l.Offsets["playerID"]; ok {
+		if err = r.Skip(n); err != nil {
 			return err
 		}
 		id, err := r.Bytes(4)
@@ -196,13 +192,15 @@ func readAtkOpSwap(r *Reader) error {
 				TimeInSeconds: r.time,
 				Operator:      o,
 			}
-			r.MatchFeedback = append(r.MatchFeedback, u)
+			if err := r.addFeedback(u); err != nil {
+				return err
+			}
 			log.Debug().Interface("match_update", u).Send()
 		}
 		return nil
 	}
 	// after Y9S3 caster view overhaul
-	if err = r.Skip(402); err != nil {
+	if err = r.Skip(l.Offsets["uiID"]); err != nil {
 		return err
 	}
 	// id shows up in player data and in op swaps afaik
@@ -220,7 +218,9 @@ func readAtkOpSwap(r *Reader) error {
 				TimeInSeconds: r.time,
 				Operator:      o,
 			}
-			r.MatchFeedback = append(r.MatchFeedback, u)
+			if err := r.addFeedback(u); err != nil {
+				return err
+			}
 			log.Debug().Interface("match_update", u).Send()
 			break
 		}
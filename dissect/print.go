@@ -5,17 +5,39 @@ import (
 )
 
 func (r *Reader) Head() {
+	r.Header.Print()
+}
+
+// Print logs a match overview from the header.
+func (h Header) Print() {
 	username := "N/A"
-	for _, p := range r.Header.Players {
-		if p.ProfileID == r.Header.RecordingProfileID {
+	for _, p := range h.Players {
+		if p.ProfileID == h.RecordingProfileID {
 			username = p.Username
 		}
 	}
-	log.Info().Msgf("Version:          %s/%d", r.Header.GameVersion, r.Header.CodeVersion)
-	log.Info().Msgf("Recording Player: %s [%s]", username, r.Header.RecordingProfileID)
-	log.Info().Msgf("Match ID:         %s", r.Header.MatchID)
-	log.Info().Msgf("Timestamp:        %s", r.Header.Timestamp.Local())
-	log.Info().Msgf("Match Type:       %s", r.Header.MatchType)
-	log.Info().Msgf("Game Mode:        %s", r.Header.GameMode)
-	log.Info().Msgf("Map:              %s", r.Header.Map)
+	log.Info().Msgf("Version:          %s/%d", h.GameVersion, h.CodeVersion)
+	log.Info().Msgf("Recording Player: %s [%s]", username, h.RecordingProfileID)
+	log.Info().Msgf("Match ID:         %s", h.MatchID)
+	log.Info().Msgf("Timestamp:        %s", h.Timestamp.Local())
+	log.Info().Msgf("Match Type:       %s", h.MatchType)
+	log.Info().Msgf("Game Mode:        %s", h.GameMode)
+	log.Info().Msgf("Map:              %s", h.Map)
+}
+
+// PrintDiagnostics logs the diagnostics collected during Read.
+func (r *Reader) PrintDiagnostics() {
+	for _, d := range r.Diagnostics {
+		e := log.Info()
+		switch d.Severity {
+		case SeverityWarning:
+			e = log.Warn()
+		case SeverityError:
+			e = log.Error()
+		}
+		e.Str("listener", d.Listener).
+			Int("offset", d.Offset).
+			Str("time", d.Time).
+			Msg(d.Message)
+	}
 }
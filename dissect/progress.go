@@ -0,0 +1,34 @@
+package dissect
+
+import "context"
+
+// progressInterval is the number of dispatched markers between progress reports.
+const progressInterval = 1000
+
+// Progress describes how far a Read has advanced.
+type Progress struct {
+	BytesDecompressed int `json:"bytesDecompressed"` // decompressed bytes available so far
+	MarkersDispatched int `json:"markersDispatched"` // pattern matches passed to listeners so far
+	TotalMarkers      int `json:"totalMarkers"`      // 0 when unknown (streaming reads)
+	RoundsCompleted   int `json:"roundsCompleted"`   // only set by MatchReader
+	TotalRounds       int `json:"totalRounds"`       // only set by MatchReader
+}
+
+// checkpoint returns the context error, if any, and reports progress
+// every progressInterval markers or when force is set.
+func (r *Reader) checkpoint(ctx context.Context, total int, force bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !force && r.markersDispatched%progressInterval != 0 {
+		return nil
+	}
+	if r.OnProgress != nil {
+		r.OnProgress(Progress{
+			BytesDecompressed: r.discarded + len(r.b),
+			MarkersDispatched: r.markersDispatched,
+			TotalMarkers:      total,
+		})
+	}
+	return nil
+}
@@ -3,12 +3,14 @@ package dissect
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"math"
+	"reflect"
 	"runtime"
-	"sort"
 	"sync"
 
 	"github.com/klauspost/compress/zstd"
@@ -17,13 +19,27 @@ import (
 
 var strSep = []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
 
+// ReaderOptions configure how a Reader reads the replay. They are set on the
+// Reader before Read and kept when ListenDefaults resets the round data.
+type ReaderOptions struct {
+	OnProgress         func(Progress) // optional callback reporting Read progress
+	Lenient            bool           // skip packets whose listener fails instead of aborting Read
+	Retain             bool           // keep the decompressed replay after Read for Rescan (disables stream compaction)
+	TrackMovement      bool           // set to true to enable movement tracking
+	MovementSampleRate int            // sample every Nth movement packet (0 = all)
+	ExperimentalTypes  bool           // capture experimental packet types (0x3F etc.) for analysis
+}
+
 type Reader struct {
+	ReaderOptions            `json:"-"`
 	b                        []byte
 	offset                   int
 	queries                  [][]byte
 	listeners                [][]func(r *Reader) error
 	time                     float64 // in seconds
 	timeRaw                  string  // raw dissect format
+	elapsed                  float64     // seconds the round clock has counted down since the first tick
+	clockTicks               []clockTick // round clock changes, for position timestamps
 	lastDefuserPlayerIndex   int
 	planted                  bool
 	defuserDisabling         bool
@@ -32,26 +48,49 @@ type Reader struct {
 	playersRead              int
 	lastKillerFromScoreboard string
 	dbnoState                map[string]string // maps victim username -> downer username (who knocked them)
+	dbnoIndex                map[string]int    // maps victim username -> index of their DBNO in MatchFeedback
+	dbnoElapsed              map[string]float64 // maps victim username -> elapsed seconds at their DBNO
+	lastShots                map[string]shot   // username -> last ammo decrease, for kill causes
+	ammoMagazines            map[uint32]int    // ammo entity ID -> last magazine ammo
+	lastPositions            map[int]PlayerPosition // player index -> last position read
 	Header                   Header        `json:"header"`
 	MatchFeedback            []MatchUpdate `json:"matchFeedback"`
 	AmmoUpdates              []AmmoUpdate  `json:"-"` // ammo state updates (populated by readAmmo)
+	AbilityUpdates           []AbilityUpdate `json:"-"` // operator ability charges used
 	Scoreboard               Scoreboard
+	src                      io.Reader // decompressed replay source when streaming
+	srcErr                   error
+	ctx                      context.Context // of the running Read, checked before decompressing more data
+	discarded                int // bytes dropped from the front of b when streaming
+	markersDispatched        int
+	subscribers              map[reflect.Type][]func(Event) error
+	listener                 func(r *Reader) error // listener being dispatched
+	packetOffset             int
+	layouts                  map[Packet]PacketLayout // packet layouts for Header.CodeVersion
+	Diagnostics              []Diagnostic `json:"-"` // anomalies found during Read
+	retained                 *retainedState
+	rescanDefaults           bool // built-in listeners registered for the current Rescan
 	playerLoadouts           map[int]PlayerLoadout      // player index -> initial loadout state
 	ammoEntityEntries              map[uint32]ammoEntityEntry // entity ID -> entry with player index + type
 	ammoLastPatternOffset          int                        // bookmarked offset for entity ID extraction
 	ammoLastNewEntityOffset        int                        // offset of last newly-seen entity (for gap detection)
 	ammoNextPlayerIdx              int                        // next player index to assign to a new entity group
 	ammoCurrentPlayerEntityCount   int                        // entities seen so far for current player group
-	TrackMovement            bool           // set to true to enable movement tracking
-	MovementSampleRate       int            // sample every Nth movement packet (0 = all)
-	ExperimentalTypes        bool           // capture experimental packet types (0x3F etc.) for analysis
 	movementCounter          int            // internal counter for sampling
 	rawPositions             []rawPosition  // raw position packets before track assignment
+	entityTracks             map[uint32]*entityTrack // non-player entity ID -> position packets
+	movements                []PlayerMovement         // built by finish
+	movementStats            map[string]MovementStats // built by finish
+	timeline                 *Timeline                // built by finish
 	experimentalPositions    []ExperimentalPacket // packets from non-standard types (0x3F etc.)
+	messageSpans             [][2]int // offset and size of feedback message strings, for Anonymize
+	playerIDOffsets          []int    // offsets of the player ids in player packets, for Anonymize
 }
 
 // NewReader decompresses in using zstd and
 // validates the dissect header.
+// The whole replay is read and decompressed into memory;
+// use NewStreamReader to keep memory bounded.
 func NewReader(in io.Reader) (r *Reader, err error) {
 	br := bufio.NewReader(in)
 	chunkedCompression, err := testFileCompression(br)
@@ -59,13 +98,7 @@ func NewReader(in io.Reader) (r *Reader, err error) {
 		return r, err
 	}
 	log.Debug().Bool("chunkedCompression (>=Y8S4)", chunkedCompression).Send()
-	r = &Reader{
-		readPartial:            false,
-		lastDefuserPlayerIndex: -1,
-		dbnoState:              make(map[string]string),
-		playerLoadouts:         make(map[int]PlayerLoadout),
-		ammoEntityEntries:      make(map[uint32]ammoEntityEntry),
-	}
+	r = newReader()
 	if chunkedCompression {
 		if err = r.readChunkedData(br); err != nil {
 			return r, err
@@ -77,28 +110,44 @@ func NewReader(in io.Reader) (r *Reader, err error) {
 	}
 	log.Debug().Int("size", len(r.b)).Send()
 	log.Debug().Str("season", r.Header.GameVersion).Int("code", r.Header.CodeVersion).Send()
-	r.Listen([]byte{0x22, 0x07, 0x94, 0x9B, 0xDC}, readPlayer)
-	r.Listen([]byte{0x22, 0xA9, 0x26, 0x0B, 0xE4}, readAtkOpSwap)
-	r.Listen([]byte{0xAF, 0x98, 0x99, 0xCA}, readSpawn)
-	if r.Header.CodeVersion >= Y8S1 {
-		r.Listen([]byte{0x1F, 0x07, 0xEF, 0xC9}, readTime)
-	} else {
-		r.Listen([]byte{0x1E, 0xF1, 0x11, 0xAB}, readY7Time)
-	}
-	r.Listen([]byte{0x59, 0x34, 0xE5, 0x8B, 0x04}, readMatchFeedback)
-	r.Listen([]byte{0x22, 0xA9, 0xC8, 0x58, 0xD9}, readDefuserTimer)
-	r.Listen([]byte{0xEC, 0xDA, 0x4F, 0x80}, readScoreboardScore)
-	r.Listen([]byte{0x4D, 0x73, 0x7F, 0x9E}, readScoreboardAssists)
-	r.Listen([]byte{0x1C, 0xD2, 0xB1, 0x9D}, readScoreboardKills)
-	// Register ammo/loadout packet listener (77 CA 96 DE)
-	// Tracks primary/secondary weapon ammo and operator ability charges per player
-	r.Listen([]byte{0x77, 0xCA, 0x96, 0xDE}, wrapAmmoReader)
-	// Register movement packet listener (only processed if TrackMovement is enabled)
-	// Player positions (00 00 60 73 85 fe) - uses position continuity tracking
-	r.Listen([]byte{0x00, 0x00, 0x60, 0x73, 0x85, 0xfe}, readPlayerPosition)
+	r.listenDefaults()
 	return r, err
 }
 
+func newReader() *Reader {
+	return &Reader{
+		readPartial:            false,
+		lastDefuserPlayerIndex: -1,
+		dbnoState:              make(map[string]string),
+		dbnoIndex:              make(map[string]int),
+		dbnoElapsed:            make(map[string]float64),
+		lastShots:              make(map[string]shot),
+		ammoMagazines:          make(map[uint32]int),
+		lastPositions:          make(map[int]PlayerPosition),
+		entityTracks:           make(map[uint32]*entityTrack),
+		playerLoadouts:         make(map[int]PlayerLoadout),
+		ammoEntityEntries:      make(map[uint32]ammoEntityEntry),
+	}
+}
+
+// listenDefaults registers the built-in packet listeners.
+// Markers come from the layouts registered for the replay's CodeVersion.
+func (r *Reader) listenDefaults() {
+	r.listenLayout(PacketPlayer, readPlayer)
+	r.listenLayout(PacketAtkOpSwap, readAtkOpSwap)
+	r.listenLayout(PacketSpawn, readSpawn)
+	r.listenLayout(PacketTime, readTime)
+	r.listenLayout(PacketY7Time, readY7Time)
+	r.listenLayout(PacketMatchFeedback, readMatchFeedback)
+	r.listenLayout(PacketDefuserTimer, readDefuserTimer)
+	r.listenLayout(PacketScoreboardScore, readScoreboardScore)
+	r.listenLayout(PacketScoreboardAssists, readScoreboardAssists)
+	r.listenLayout(PacketScoreboardKills, readScoreboardKills)
+	r.listenLayout(PacketAmmo, wrapAmmoReader)
+	// Player positions - uses position continuity tracking
+	r.listenLayout(PacketPosition, readPlayerPosition)
+}
+
 func (r *Reader) readChunkedData(genericReader io.Reader) error {
 	log.Debug().Msg("reading data")
 	temp, err := io.ReadAll(genericReader)
@@ -153,9 +202,7 @@ func (r *Reader) readChunkedData(genericReader io.Reader) error {
 		if err != nil && !(len(decompressed) > 0 && errors.Is(err, zstd.ErrMagicMismatch)) {
 			return err
 		}
-		for _, b := range decompressed {
-			data = append(data, b)
-		}
+		data = append(data, decompressed...)
 		r.offset += tempReader.n
 	}
 	r.b = data
@@ -187,76 +234,131 @@ type match struct {
 	listenerIndex int
 }
 
-func (r *Reader) worker(start int, end int, wg *sync.WaitGroup, matches chan<- match) {
-	defer wg.Done()
-	indexes := make([]int, len(r.queries))
-	log.Debug().Int("start", start).Int("end", end).Msg("worker")
-	for i := start; i <= end; i++ {
-		for j, query := range r.queries {
-			if r.b[i] == query[indexes[j]] {
-				indexes[j]++
-				if indexes[j] == len(query) {
-					indexes[j] = 0
-					matches <- match{i, j}
-				}
-			} else {
-				indexes[j] = 0
+// minBlockSize is the smallest block of the replay handed to a scan worker.
+const minBlockSize = 64 * 1024
+
+// scanChunkSize is the number of bytes a scan worker reads between cancellation checks.
+const scanChunkSize = 1024 * 1024
+
+// scan returns every query match in r.b[start:end] ordered by offset.
+// The range is split across GOMAXPROCS workers. Each worker starts
+// maxLen-1 bytes before its block so patterns straddling a boundary
+// are found, but only reports matches ending inside its own block.
+// Workers stop with the context error once ctx is done.
+func (r *Reader) scan(ctx context.Context, a *automaton, start int, end int) ([]match, error) {
+	n := end - start
+	numWorkers := min(runtime.GOMAXPROCS(0), n/minBlockSize)
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	blockSize := (n + numWorkers - 1) / numWorkers
+	log.Debug().Int("workers", numWorkers).Int("blockSize", blockSize).Send()
+	results := make([][]match, numWorkers)
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		blockStart := start + i*blockSize
+		blockEnd := min(blockStart+blockSize, end)
+		from := max(blockStart-(a.maxLen-1), start)
+		go func(i int) {
+			defer wg.Done()
+			state := int32(0)
+			for chunk := from; chunk < blockEnd && ctx.Err() == nil; chunk += scanChunkSize {
+				chunkEnd := min(chunk+scanChunkSize, blockEnd)
+				state, results[i] = a.scan(r.b[chunk:chunkEnd], state, chunk, blockStart, results[i])
 			}
-		}
+		}(i)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+	matches := make([]match, 0)
+	for _, result := range results {
+		matches = append(matches, result...)
+	}
+	return matches, nil
 }
 
 // Read continues reading the replay past the header until the EOF.
-func (r *Reader) Read() (err error) {
-	numWorkers := 5
-	var wg sync.WaitGroup
-	channel := make(chan match, 300)
-	start := r.offset
+func (r *Reader) Read() error {
+	return r.ReadContext(context.Background())
+}
+
+// ReadContext is like Read, but stops with the context error
+// once ctx is done. Progress is reported to OnProgress.
+func (r *Reader) ReadContext(ctx context.Context) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	r.retain()
+	r.ctx = ctx
+	defer func() { r.ctx = nil }()
+	if r.src != nil {
+		return r.readStream(ctx)
+	}
 	end := len(r.b)
 	if r.readPartial {
 		end /= 3
 	}
-	blockSize := int(math.Floor(float64(end-start) / float64(numWorkers)))
-	log.Debug().Int("workers", numWorkers).Int("blockSize", blockSize).Send()
-	wg.Add(numWorkers)
-	for i := 0; i < numWorkers; i++ {
-		blockStart := r.offset + (i * blockSize)
-		blockEnd := blockStart + blockSize
-		if i > 0 {
-			blockStart += 1
-		}
-		if i == numWorkers-1 {
-			blockEnd = end - 1
-		}
-		go r.worker(blockStart, blockEnd, &wg, channel)
+	if err = r.scanAndDispatch(ctx, end); err != nil {
+		return
 	}
-	go func() {
-		wg.Wait()
-		close(channel)
-	}()
-	matches := make([]match, 0)
-	log.Debug().Msg("reading from channel")
-	for match := range channel {
-		matches = append(matches, match)
+	return r.finish()
+}
+
+// scanAndDispatch runs the registered listeners over the buffered replay up to end.
+func (r *Reader) scanAndDispatch(ctx context.Context, end int) error {
+	matches, err := r.scan(ctx, newAutomaton(r.queries), r.offset, end)
+	if err != nil {
+		return err
 	}
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].offset < matches[j].offset
-	})
 	log.Debug().Int("matches", len(matches)).Msg("calling listeners")
 	for _, entry := range matches {
-		for _, listener := range r.listeners[entry.listenerIndex] {
-			r.offset = entry.offset + 1
-			if err = listener(r); err != nil {
-				return
-			}
+		if err := r.dispatch(entry); err != nil {
+			return err
+		}
+		if err := r.checkpoint(ctx, len(matches), false); err != nil {
+			return err
 		}
 	}
+	return r.checkpoint(ctx, len(matches), true)
+}
+
+// dispatch runs the listeners of a match with the offset
+// positioned right after the matched pattern.
+func (r *Reader) dispatch(m match) error {
+	r.markersDispatched++
+	r.packetOffset = r.discarded + m.offset + 1
+	for _, listener := range r.listeners[m.listenerIndex] {
+		r.offset = m.offset + 1
+		r.listener = listener
+		err := listener(r)
+		r.listener = nil
+		if err != nil && !r.skippable(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish decodes the round data derived from the whole replay. It returns
+// the error of any event handler called for it.
+func (r *Reader) finish() (err error) {
 	if !r.readPartial {
 		// Populate player loadout data from captured ammo updates
 		r.populateLoadouts()
-		r.roundEnd()
+		r.movements = r.newMovementData()
+		if err = r.emitRoomChanges(); err == nil {
+			err = r.roundEnd()
+		}
+		r.movementStats = r.newMovementStats()
+		r.timeline = r.newTimeline()
 	}
-	r.b = nil
+	if !r.Retain {
+		r.b = nil
+	}
+	r.src = nil
 	return err
 }
 
@@ -264,9 +366,14 @@ func (r *Reader) Read() (err error) {
 // This information does not include dynamic data, such as attack operator swaps.
 // Use ReadPartial for faster, minimal reads.
 func (r *Reader) ReadPartial() error {
+	return r.ReadPartialContext(context.Background())
+}
+
+// ReadPartialContext is like ReadPartial, but stops with the context error once ctx is done.
+func (r *Reader) ReadPartialContext(ctx context.Context) error {
 	r.readPartial = true
 	log.Debug().Msg("using partial read")
-	err := r.Read()
+	err := r.ReadContext(ctx)
 	r.readPartial = false
 	return err
 }
@@ -274,11 +381,10 @@ func (r *Reader) ReadPartial() error {
 // Listen registers a callback to be run during Read whenever
 // the pattern is found.
 func (r *Reader) Listen(pattern []byte, callback func(r *Reader) error) {
-	var i int
-	for i = 0; i < len(r.queries); i++ {
+	for i := 0; i < len(r.queries); i++ {
 		if bytes.Equal(r.queries[i], pattern) {
 			r.listeners[i] = append(r.listeners[i], callback)
-			break
+			return
 		}
 	}
 	r.queries = append(r.queries, pattern)
@@ -300,6 +406,7 @@ func (r *Reader) Seek(pattern []byte) error {
 				} else {
 					log.Warn().Int("bytes", r.offset-start).Msg("large seek")
 				}
+				r.diagnose(SeverityWarning, fmt.Sprintf("large seek of %d bytes for %X", r.offset-start, pattern))
 			}
 			return err
 		}
@@ -317,8 +424,13 @@ func (r *Reader) Seek(pattern []byte) error {
 // Skip increases the replay offset by n bytes.
 func (r *Reader) Skip(n int) error {
 	r.offset += n
-	if r.offset >= len(r.b) {
-		return io.EOF
+	for r.offset >= len(r.b) {
+		if err := r.fill(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return io.EOF
+			}
+			return err
+		}
 	}
 	return nil
 }
@@ -390,13 +502,24 @@ func (r *Reader) Float32() (float32, error) {
 	return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
 }
 
+// Write writes the decompressed replay to w.
+// For readers created with NewStreamReader, the rest of the
+// stream is consumed, so Write should not be combined with Read.
 func (r *Reader) Write(w io.Writer) (n int, err error) {
-	return w.Write(r.b)
+	n, err = w.Write(r.b)
+	if err != nil || r.src == nil {
+		return
+	}
+	written, err := io.Copy(w, r.src)
+	r.src = nil
+	return n + int(written), err
 }
 
 // ClearDBNOState removes a player from the DBNO tracking (e.g., when they are revived or killed)
 func (r *Reader) ClearDBNOState(username string) {
 	delete(r.dbnoState, username)
+	delete(r.dbnoIndex, username)
+	delete(r.dbnoElapsed, username)
 }
 
 // GetDowner returns who downed a player, if they are in DBNO state
@@ -0,0 +1,82 @@
+package dissect
+
+import (
+	"context"
+	"slices"
+)
+
+// retainedState is what Rescan needs to run another pass over the replay.
+type retainedState struct {
+	bodyOffset int    // offset of the first byte after the header
+	header     Header // header before any listener ran
+}
+
+// retain records the start of the body and the header on the first Read with Retain set.
+func (r *Reader) retain() {
+	if !r.Retain || r.retained != nil {
+		return
+	}
+	h := r.Header
+	h.Players = slices.Clone(h.Players)
+	r.retained = &retainedState{
+		bodyOffset: r.position(),
+		header:     h,
+	}
+}
+
+// Rescan runs another pass over the replay retained by Read (see Retain)
+// with a fresh set of listeners. Listeners and subscribers of earlier passes
+// are removed before listen registers the new ones with Listen, Subscribe
+// or ListenDefaults. Round data decoded by earlier passes is kept,
+// unless ListenDefaults is called to rebuild it.
+func (r *Reader) Rescan(listen func(r *Reader)) error {
+	return r.RescanContext(context.Background(), listen)
+}
+
+// RescanContext is like Rescan, but stops with the context error once ctx is done.
+func (r *Reader) RescanContext(ctx context.Context, listen func(r *Reader)) error {
+	if r.retained == nil || r.b == nil || r.src != nil {
+		return ErrNotRetained
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.queries = nil
+	r.listeners = nil
+	r.subscribers = nil
+	r.rescanDefaults = false
+	r.markersDispatched = 0
+	listen(r)
+	r.offset = r.retained.bodyOffset
+	if err := r.scanAndDispatch(ctx, len(r.b)); err != nil {
+		return err
+	}
+	if r.rescanDefaults {
+		return r.finish()
+	}
+	return nil
+}
+
+// ListenDefaults registers the built-in listeners for a Rescan and clears
+// the round data they decode, e.g. to rebuild it with TrackMovement enabled.
+func (r *Reader) ListenDefaults() {
+	r.resetRound()
+	r.listenDefaults()
+	r.rescanDefaults = true
+}
+
+// resetRound restores the state of the Reader before Read,
+// keeping the replay, registered listeners and options.
+func (r *Reader) resetRound() {
+	fresh := newReader()
+	fresh.b = r.b
+	fresh.retained = r.retained
+	fresh.Header = r.retained.header
+	fresh.Header.Players = slices.Clone(r.retained.header.Players)
+	fresh.queries = r.queries
+	fresh.listeners = r.listeners
+	fresh.subscribers = r.subscribers
+	fresh.layouts = r.layouts
+	fresh.ReaderOptions = r.ReaderOptions
+	*r = *fresh
+}
@@ -1,23 +1,50 @@
 package dissect
 
-// RoomBounds defines the 3D bounding box for a room.
-type RoomBounds struct {
-	Name string  `json:"name"` // e.g., "2F Master Bedroom"
-	MinX float32 `json:"minX"`
-	MaxX float32 `json:"maxX"`
-	MinY float32 `json:"minY"`
-	MaxY float32 `json:"maxY"`
-	MinZ float32 `json:"minZ"` // Floor height range
-	MaxZ float32 `json:"maxZ"`
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// RoomsVersion is the version of the room geometry format read by LoadRooms.
+const RoomsVersion = 1
+
+// Floor is a horizontal slice of a map between two heights.
+type Floor struct {
+	Name    string  `json:"name"` // e.g., "2F"
+	MinZ    float32 `json:"minZ"`
+	MaxZ    float32 `json:"maxZ"`
+	Outdoor bool    `json:"outdoor,omitempty"` // e.g., the roof
 }
 
-// MapRooms contains room definitions for a specific map.
-type MapRooms struct {
-	MapName string       `json:"mapName"`
-	Rooms   []RoomBounds `json:"rooms"`
+// Room is the outline of a room on a floor.
+type Room struct {
+	Name    string       `json:"name"` // e.g., "2F Master Bedroom"
+	Floor   string       `json:"floor"`
+	Polygon [][2]float32 `json:"polygon"` // x, y vertices in order
+}
+
+// MapGeometry contains the floors and rooms of a map.
+type MapGeometry struct {
+	Map    string  `json:"map"` // Map.String(), e.g., "ChaletY10"
+	Floors []Floor `json:"floors"`
+	Rooms  []Room  `json:"rooms"`
+	// Complete is set when the rooms, hallways and stairs cover every indoor
+	// position, so a position outside the rooms is outside the building.
+	Complete bool `json:"complete,omitempty"`
 }
 
-// Floor height ranges for R6 maps (approximate)
+// RoomsFile is the room geometry format read by LoadRooms.
+type RoomsFile struct {
+	Version int           `json:"version"`
+	Maps    []MapGeometry `json:"maps"`
+}
+
+// Floor height ranges for R6 maps (approximate), used for maps without geometry.
 const (
 	BasementMinZ = -5.0
 	BasementMaxZ = 0.0
@@ -47,82 +74,255 @@ func GetFloorName(z float32) string {
 	}
 }
 
-// mapRoomDefinitions contains room data for supported maps.
-// Note: This is a starting point. Full room boundaries need to be collected
-// through gameplay data analysis or extracted from game files.
-var mapRoomDefinitions = map[string]*MapRooms{
-	// Chalet (ChaletY10) - based on coordinate analysis from sample replays
-	"ChaletY10": {
-		MapName: "ChaletY10",
-		Rooms: []RoomBounds{
-			// 2F Rooms (approximate based on spawn data showing Z ~4.4-7.0)
-			{Name: "2F Master Bedroom", MinX: -15, MaxX: 0, MinY: -25, MaxY: -15, MinZ: Floor2MinZ, MaxZ: Floor2MaxZ},
-			{Name: "2F Office", MinX: 0, MaxX: 15, MinY: -25, MaxY: -15, MinZ: Floor2MinZ, MaxZ: Floor2MaxZ},
-			{Name: "2F Library", MinX: -15, MaxX: 0, MinY: 10, MaxY: 25, MinZ: Floor2MinZ, MaxZ: Floor2MaxZ},
-			{Name: "2F Trophy Room", MinX: 0, MaxX: 15, MinY: 10, MaxY: 25, MinZ: Floor2MinZ, MaxZ: Floor2MaxZ},
-			// 1F Rooms
-			{Name: "1F Bar", MinX: -15, MaxX: 0, MinY: -25, MaxY: -10, MinZ: Floor1MinZ, MaxZ: Floor1MaxZ},
-			{Name: "1F Gaming Room", MinX: 0, MaxX: 15, MinY: -25, MaxY: -10, MinZ: Floor1MinZ, MaxZ: Floor1MaxZ},
-			{Name: "1F Kitchen", MinX: -15, MaxX: 0, MinY: 5, MaxY: 20, MinZ: Floor1MinZ, MaxZ: Floor1MaxZ},
-			{Name: "1F Dining Room", MinX: 0, MaxX: 15, MinY: 5, MaxY: 20, MinZ: Floor1MinZ, MaxZ: Floor1MaxZ},
-			// Basement
-			{Name: "B Wine Cellar", MinX: -15, MaxX: 0, MinY: -20, MaxY: 0, MinZ: BasementMinZ, MaxZ: BasementMaxZ},
-			{Name: "B Snowmobile Garage", MinX: 0, MaxX: 15, MinY: -20, MaxY: 0, MinZ: BasementMinZ, MaxZ: BasementMaxZ},
-		},
-	},
+// defaultFloors are the floors of GetFloorName, for maps without geometry.
+var defaultFloors = []Floor{
+	{Name: "B", MinZ: BasementMinZ, MaxZ: BasementMaxZ},
+	{Name: "1F", MinZ: Floor1MinZ, MaxZ: Floor1MaxZ},
+	{Name: "2F", MinZ: Floor2MinZ, MaxZ: Floor2MaxZ},
+	{Name: "3F", MinZ: Floor3MinZ, MaxZ: Floor3MaxZ},
+	{Name: "Roof", MinZ: RoofMinZ, MaxZ: RoofMaxZ, Outdoor: true},
+}
+
+// defaultRooms is the room geometry registered at init.
+// Note: The rooms are approximate, based on coordinate analysis from sample replays.
+//
+//go:embed rooms.json
+var defaultRooms []byte
+
+var rooms = struct {
+	sync.RWMutex
+	m map[string]MapGeometry
+}{m: make(map[string]MapGeometry)}
+
+func init() {
+	if err := LoadRooms(bytes.NewReader(defaultRooms)); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterMapGeometry registers the floors and rooms of a map,
+// replacing any geometry registered for the same map.
+func RegisterMapGeometry(g MapGeometry) {
+	rooms.Lock()
+	defer rooms.Unlock()
+	rooms.m[g.Map] = g
+}
+
+// MapGeometryFor returns the geometry registered for the map.
+func MapGeometryFor(mapName string) (MapGeometry, bool) {
+	rooms.RLock()
+	defer rooms.RUnlock()
+	g, ok := rooms.m[mapName]
+	return g, ok
+}
+
+// LoadRooms registers the maps of a RoomsFile, e.g. to add a map or
+// correct the rooms of one without recompiling.
+func LoadRooms(in io.Reader) error {
+	var f RoomsFile
+	if err := json.NewDecoder(in).Decode(&f); err != nil {
+		return err
+	}
+	if f.Version != RoomsVersion {
+		return fmt.Errorf("rooms: unsupported version %d, want %d", f.Version, RoomsVersion)
+	}
+	for _, g := range f.Maps {
+		if err := g.validate(); err != nil {
+			return err
+		}
+	}
+	for _, g := range f.Maps {
+		RegisterMapGeometry(g)
+	}
+	return nil
+}
+
+// validate checks that the floors are ordered and every room has
+// an outline on one of them.
+func (g MapGeometry) validate() error {
+	if g.Map == "" {
+		return errors.New("rooms: missing map")
+	}
+	if len(g.Floors) == 0 {
+		return fmt.Errorf("rooms: %s: missing floors", g.Map)
+	}
+	for i, f := range g.Floors {
+		if f.MinZ >= f.MaxZ || (i > 0 && f.MinZ < g.Floors[i-1].MaxZ) {
+			return fmt.Errorf("rooms: %s: floor %q is not above the floor below it", g.Map, f.Name)
+		}
+	}
+	for _, room := range g.Rooms {
+		if _, ok := g.floorNamed(room.Floor); !ok {
+			return fmt.Errorf("rooms: %s: room %q is on unknown floor %q", g.Map, room.Name, room.Floor)
+		}
+		if len(room.Polygon) < 3 {
+			return fmt.Errorf("rooms: %s: room %q needs at least 3 vertices", g.Map, room.Name)
+		}
+	}
+	return nil
+}
+
+func (g MapGeometry) floorNamed(name string) (Floor, bool) {
+	for _, f := range g.Floors {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Floor{}, false
+}
+
+// floor returns the floor at the height. Heights below the lowest or above
+// the highest floor belong to that floor.
+func (g MapGeometry) floor(z float32) Floor {
+	for _, f := range g.Floors {
+		if z < f.MaxZ {
+			return f
+		}
+	}
+	return g.Floors[len(g.Floors)-1]
+}
+
+// room returns the room containing the coordinates.
+func (g MapGeometry) room(x, y, z float32) (Room, bool) {
+	floor := g.floor(z)
+	for _, room := range g.Rooms {
+		if room.Floor == floor.Name && room.contains(x, y) {
+			return room, true
+		}
+	}
+	return Room{}, false
+}
+
+// contains reports whether the point is within the outline of the room.
+func (room Room) contains(x, y float32) bool {
+	inside := false
+	p := room.Polygon
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		if (p[i][1] > y) != (p[j][1] > y) &&
+			x < (p[j][0]-p[i][0])*(y-p[i][1])/(p[j][1]-p[i][1])+p[i][0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// mapFloors returns the floors of the map from the bottom up.
+func mapFloors(mapName string) []Floor {
+	if g, ok := MapGeometryFor(mapName); ok {
+		return g.Floors
+	}
+	return defaultFloors
+}
+
+// FloorAt returns the name of the floor at the height on the map,
+// falling back to GetFloorName for maps without geometry.
+func FloorAt(mapName string, z float32) string {
+	g, ok := MapGeometryFor(mapName)
+	if !ok {
+		return GetFloorName(z)
+	}
+	return g.floor(z).Name
 }
 
 // GetRoomAtPosition returns the room name for the given coordinates and map.
-// Returns empty string if no room is found or map is not supported.
+// Returns the floor name if no room is found or the map is not supported.
 func GetRoomAtPosition(mapName string, x, y, z float32) string {
-	mapRooms, ok := mapRoomDefinitions[mapName]
+	g, ok := MapGeometryFor(mapName)
 	if !ok {
-		// Map not supported, return floor only
 		return GetFloorName(z)
 	}
+	if room, ok := g.room(x, y, z); ok {
+		return room.Name
+	}
+	return g.floor(z).Name
+}
 
-	for _, room := range mapRooms.Rooms {
-		if x >= room.MinX && x <= room.MaxX &&
-			y >= room.MinY && y <= room.MaxY &&
-			z >= room.MinZ && z <= room.MaxZ {
-			return room.Name
-		}
+// insideBuilding reports whether the coordinates are inside the building of the map.
+// Outdoor floors are outside. known is false for maps without complete geometry.
+func insideBuilding(mapName string, x, y, z float32) (inside, known bool) {
+	g, ok := MapGeometryFor(mapName)
+	if !ok || !g.Complete {
+		return false, false
+	}
+	if g.floor(z).Outdoor {
+		return false, true
 	}
+	_, ok = g.room(x, y, z)
+	return ok, true
+}
 
-	// No specific room found, return floor
-	return GetFloorName(z)
+// RoomReport lists the tracked positions of a round outside every room of
+// its map, to find gaps in the room geometry.
+type RoomReport struct {
+	Map       string            `json:"map"`
+	Positions int               `json:"positions"` // tracked positions checked
+	Outside   []OutsidePosition `json:"outside"`
 }
 
-// PositionWithRoom extends PlayerPosition with room information.
-type PositionWithRoom struct {
+// OutsidePosition is a tracked position outside every room.
+type OutsidePosition struct {
+	Username string `json:"username"`
+	Floor    string `json:"floor"`
 	PlayerPosition
-	Room string `json:"room,omitempty"`
 }
 
-// GetMovementDataWithRooms returns movement data with room names populated.
-func (r *Reader) GetMovementDataWithRooms() []PlayerMovement {
-	movements := r.GetMovementData()
-	if movements == nil {
-		return nil
+// ValidateRooms checks the tracked positions of the round against the
+// room geometry of its map. Positions are only tracked if movement tracking
+// was enabled during Read. ErrNoRooms is returned for maps without geometry.
+func (r *Reader) ValidateRooms() (RoomReport, error) {
+	mapName := r.Header.Map.String()
+	report := RoomReport{Map: mapName, Outside: make([]OutsidePosition, 0)}
+	g, ok := MapGeometryFor(mapName)
+	if !ok {
+		return report, fmt.Errorf("%w %s", ErrNoRooms, mapName)
+	}
+	for _, m := range r.GetMovementData() {
+		for _, pos := range m.Positions {
+			report.Positions++
+			if _, ok := g.room(pos.X, pos.Y, pos.Z); !ok {
+				report.Outside = append(report.Outside, OutsidePosition{
+					Username:       m.Username,
+					Floor:          g.floor(pos.Z).Name,
+					PlayerPosition: pos,
+				})
+			}
+		}
 	}
+	return report, nil
+}
 
-	mapName := r.Header.Map.String()
+// GetMovementDataWithRooms returns movement data with room names populated.
+//
+// Deprecated: GetMovementData populates the room of every position.
+func (r *Reader) GetMovementDataWithRooms() []PlayerMovement {
+	return r.GetMovementData()
+}
 
-	// Create new movements with room data
-	result := make([]PlayerMovement, len(movements))
-	for i, pm := range movements {
-		result[i] = PlayerMovement{
-			Username:  pm.Username,
-			Positions: make([]PlayerPosition, len(pm.Positions)),
-		}
+// PositionWithRoom extends PlayerPosition with room information.
+//
+// Deprecated: PlayerPosition has a Room field.
+type PositionWithRoom struct {
+	PlayerPosition
+	Room string `json:"room,omitempty"`
+}
 
-		for j, pos := range pm.Positions {
-			result[i].Positions[j] = pos
-			// Note: Room lookup would require extending PlayerPosition
-			// For now, we just copy the positions
-			_ = GetRoomAtPosition(mapName, pos.X, pos.Y, pos.Z)
-		}
-	}
+// RoomBounds defines the 3D bounding box for a room.
+//
+// Deprecated: rooms are outlined by a Room polygon on a Floor of a MapGeometry.
+type RoomBounds struct {
+	Name string  `json:"name"` // e.g., "2F Master Bedroom"
+	MinX float32 `json:"minX"`
+	MaxX float32 `json:"maxX"`
+	MinY float32 `json:"minY"`
+	MaxY float32 `json:"maxY"`
+	MinZ float32 `json:"minZ"` // Floor height range
+	MaxZ float32 `json:"maxZ"`
+}
 
-	return result
+// MapRooms contains room definitions for a specific map.
+//
+// Deprecated: use MapGeometry, registered with RegisterMapGeometry or LoadRooms.
+type MapRooms struct {
+	MapName string       `json:"mapName"`
+	Rooms   []RoomBounds `json:"rooms"`
 }
@@ -0,0 +1,132 @@
+package dissect
+
+import "sort"
+
+// minRoomStay is how many seconds a player must stay in a room for a visit
+// to count, so positions jittering across a wall do not split visits.
+const minRoomStay = 1.0
+
+// RoomVisit is a stay of a player in a room. Positions outside every room
+// are labeled with their floor, see GetRoomAtPosition.
+type RoomVisit struct {
+	Room         string  `json:"room"`
+	EnterTime    float64 `json:"enterTime"`    // round clock, as in MatchUpdate
+	LeaveTime    float64 `json:"leaveTime"`    // round clock, as in MatchUpdate
+	EnterElapsed float64 `json:"enterElapsed"` // seconds since the first clock tick of the replay
+	LeaveElapsed float64 `json:"leaveElapsed"` // seconds since the first clock tick of the replay
+}
+
+// Duration returns the seconds the player stayed in the room.
+func (v RoomVisit) Duration() float64 {
+	return v.LeaveElapsed - v.EnterElapsed
+}
+
+// PlayerRooms is the room timeline of a player, in order of the visits.
+type PlayerRooms struct {
+	Username string      `json:"username"`
+	Operator string      `json:"operator"`
+	Team     string      `json:"team"` // "Attack" or "Defense"
+	Visits   []RoomVisit `json:"visits"`
+}
+
+// RoomChangeEvent is emitted when a tracked player moves from one room to another.
+// Room changes are derived from the movement data after the replay is read,
+// so they are only emitted with TrackMovement enabled, after every event
+// decoded from the replay and before the RoundEnd.
+type RoomChangeEvent struct {
+	Username       string  `json:"username"`
+	Operator       string  `json:"operator"`
+	Team           string  `json:"team"`
+	From           string  `json:"from"`
+	To             string  `json:"to"`
+	TimeInSeconds  float64 `json:"timeInSeconds"`
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+}
+
+// RoomTimeline returns the rooms each tracked player visited during the round.
+// It is empty unless movement tracking was enabled during Read.
+func (r *Reader) RoomTimeline() []PlayerRooms {
+	movements := r.GetMovementData()
+	if len(movements) == 0 {
+		return nil
+	}
+	timeline := make([]PlayerRooms, 0, len(movements))
+	for _, m := range movements {
+		timeline = append(timeline, PlayerRooms{
+			Username: m.Username,
+			Operator: m.Operator,
+			Team:     m.Team,
+			Visits:   roomVisits(m.Positions),
+		})
+	}
+	return timeline
+}
+
+// roomVisits groups positions ordered by ElapsedSeconds into visits.
+// A player enters a room once they stay in it for minRoomStay.
+func roomVisits(positions []PlayerPosition) []RoomVisit {
+	visits := make([]RoomVisit, 0)
+	if len(positions) == 0 {
+		return visits
+	}
+	enter := func(p PlayerPosition) {
+		visits = append(visits, RoomVisit{
+			Room:         p.Room,
+			EnterTime:    p.TimeInSeconds,
+			EnterElapsed: p.ElapsedSeconds,
+		})
+	}
+	leave := func(p PlayerPosition) {
+		visits[len(visits)-1].LeaveTime = p.TimeInSeconds
+		visits[len(visits)-1].LeaveElapsed = p.ElapsedSeconds
+	}
+	enter(positions[0])
+	candidate := -1 // first position in another room
+	for i, p := range positions {
+		current := visits[len(visits)-1].Room
+		switch {
+		case p.Room == current:
+			candidate = -1
+		case candidate < 0 || positions[candidate].Room != p.Room:
+			candidate = i
+		}
+		if candidate >= 0 && p.ElapsedSeconds-positions[candidate].ElapsedSeconds >= minRoomStay {
+			leave(positions[candidate])
+			enter(positions[candidate])
+			candidate = -1
+		}
+	}
+	leave(positions[len(positions)-1])
+	return visits
+}
+
+// emitRoomChanges emits a RoomChangeEvent for every room change in the
+// room timeline, in order of time. It returns the first handler error.
+func (r *Reader) emitRoomChanges() error {
+	if !r.TrackMovement || !r.subscribed(RoomChangeEvent{}) {
+		return nil
+	}
+	changes := make([]RoomChangeEvent, 0)
+	for _, p := range r.RoomTimeline() {
+		for i := 1; i < len(p.Visits); i++ {
+			changes = append(changes, RoomChangeEvent{
+				Username:       p.Username,
+				Operator:       p.Operator,
+				Team:           p.Team,
+				From:           p.Visits[i-1].Room,
+				To:             p.Visits[i].Room,
+				TimeInSeconds:  p.Visits[i].EnterTime,
+				ElapsedSeconds: p.Visits[i].EnterElapsed,
+			})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ElapsedSeconds < changes[j].ElapsedSeconds
+	})
+	for _, e := range changes {
+		if err := r.emit(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
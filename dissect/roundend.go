@@ -0,0 +1,94 @@
+package dissect
+
+import (
+	"slices"
+
+	"github.com/rs/zerolog/log"
+)
+
+// recordRoundEnd adds a RoundEnd update with the outcome decided by roundEnd.
+func (r *Reader) recordRoundEnd() error {
+	alive, left := r.aliveCounts()
+	u := MatchUpdate{
+		Type:  RoundEnd,
+		Alive: alive[:],
+	}
+	winner := -1
+	for i, team := range r.Header.Teams {
+		if team.Won {
+			winner = i
+			break
+		}
+	}
+	if winner >= 0 {
+		loser := winner ^ 1
+		if r.NumPlayers(loser) > 0 && left[loser] == r.NumPlayers(loser) {
+			r.Header.Teams[winner].WinCondition = Forfeit
+		}
+		u.Role = r.Header.Teams[winner].Role
+		u.WinCondition = r.Header.Teams[winner].WinCondition
+	}
+	// The replay continues for a few seconds after the deciding event, so the
+	// time is left empty when the deciding moment is unknown.
+	if decisive, ok := r.decisiveUpdate(u.WinCondition); ok {
+		u.Time = decisive.Time
+		u.TimeInSeconds = decisive.TimeInSeconds
+	}
+	if err := r.addFeedback(u); err != nil {
+		return err
+	}
+	log.Debug().Interface("match_update", u).Send()
+	return nil
+}
+
+// aliveCounts returns the number of players of each team still alive and
+// the number of players of each team who left the match.
+func (r *Reader) aliveCounts() (alive [2]int, left [2]int) {
+	gone := make(map[string]bool)
+	for _, u := range r.MatchFeedback {
+		if victim, ok := u.victim(); ok {
+			gone[victim] = true
+		} else if u.Type == PlayerLeave {
+			if i := r.PlayerIndexByUsername(u.Username); i >= 0 && !gone[u.Username] {
+				left[r.Header.Players[i].TeamIndex&1]++
+			}
+			gone[u.Username] = true
+		}
+	}
+	for _, p := range r.Header.Players {
+		if !gone[p.Username] {
+			alive[p.TeamIndex&1]++
+		}
+	}
+	return
+}
+
+// decisiveUpdate returns the update that decided the round with the win condition.
+// Rounds won on time end when the clock is seen running out. The detonation of the
+// defuser is not in the replay, so rounds won by DefusedBomb have no deciding update.
+func (r *Reader) decisiveUpdate(condition WinCondition) (MatchUpdate, bool) {
+	if condition == Time {
+		ranOut := slices.ContainsFunc(r.clockTicks, func(t clockTick) bool { return t.time == 0 })
+		return MatchUpdate{Time: "0:00"}, ranOut
+	}
+	decides := map[WinCondition]func(u MatchUpdate) bool{
+		KilledOpponents: func(u MatchUpdate) bool {
+			_, died := u.victim()
+			return died
+		},
+		DisabledDefuser:  func(u MatchUpdate) bool { return u.Type == DefuserDisableComplete },
+		SecuredArea:      func(u MatchUpdate) bool { return u.Type == AreaSecured },
+		ExtractedHostage: func(u MatchUpdate) bool { return u.Type == HostageExtracted },
+		KilledHostage:    func(u MatchUpdate) bool { return u.Type == HostageKill },
+		Forfeit:          func(u MatchUpdate) bool { return u.Type == PlayerLeave },
+	}[condition]
+	if decides == nil {
+		return MatchUpdate{}, false
+	}
+	for i := len(r.MatchFeedback) - 1; i >= 0; i-- {
+		if u := r.MatchFeedback[i]; decides(u) {
+			return u, true
+		}
+	}
+	return MatchUpdate{}, false
+}
@@ -19,7 +19,7 @@ func readScoreboardKills(r *Reader) error {
 	if err != nil {
 		return err
 	}
-	if err := r.Skip(30); err != nil {
+	if err := r.Skip(r.layout(PacketScoreboardKills).Offsets["playerID"]); err != nil {
 		return err
 	}
 	id, err := r.Bytes(4)
@@ -46,7 +46,7 @@ func readScoreboardAssists(r *Reader) error {
 	if assists == 0 {
 		return nil
 	}
-	if err = r.Skip(30); err != nil {
+	if err = r.Skip(r.layout(PacketScoreboardAssists).Offsets["playerID"]); err != nil {
 		return err
 	}
 	id, err := r.Bytes(4)
@@ -75,7 +75,7 @@ func readScoreboardScore(r *Reader) error {
 	if score == 0 {
 		return nil
 	}
-	if err = r.Skip(13); err != nil {
+	if err = r.Skip(r.layout(PacketScoreboardScore).Offsets["playerID"]); err != nil {
 		return err
 	}
 	id, err := r.Bytes(4)
@@ -14,7 +14,7 @@ func readSpawn(r *Reader) error {
 	if err != nil {
 		return err
 	}
-	if err = r.Skip(150); err != nil {
+	if err = r.Skip(r.layout(PacketSpawn).Offsets["sitePattern"]); err != nil {
 		return err
 	}
 	pattern, err := r.Bytes(5)
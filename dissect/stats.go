@@ -1,43 +1,59 @@
 package dissect
 
 type PlayerRoundStats struct {
-	Username           string  `json:"username"`
-	TeamIndex          int     `json:"-"`
-	Score              int     `json:"score"`
-	Operator           string  `json:"-"`
-	Kills              int     `json:"kills"`
-	Died               bool    `json:"died"`
-	Assists            int     `json:"assists"`
-	Headshots          int     `json:"headshots"`
-	HeadshotPercentage float64 `json:"headshotPercentage"`
-	OneVx              int     `json:"1vX,omitempty"`
+	Username           string            `json:"username"`
+	TeamIndex          int               `json:"-"`
+	Score              int               `json:"score"`
+	Operator           string            `json:"-"`
+	Kills              int               `json:"kills"`
+	TeamKills          int               `json:"teamKills"`
+	Died               bool              `json:"died"`
+	Assists            int               `json:"assists"`
+	Headshots          int               `json:"headshots"`
+	HeadshotPercentage float64           `json:"headshotPercentage"`
+	OneVx              int               `json:"1vX,omitempty"`
+	Revived            int               `json:"revived,omitempty"`   // times the player was revived, not by them (revivers are not recorded)
+	SlotKills          map[KillCause]int `json:"slotKills,omitempty"` // kills per weapon slot, see KillCause
+	AreaSecures        int               `json:"areaSecures,omitempty"`
+	HostagePickups     int               `json:"hostagePickups,omitempty"`
+	HostageExtractions int               `json:"hostageExtractions,omitempty"`
+	AbilitiesUsed      int               `json:"abilitiesUsed,omitempty"` // operator ability charges
+	Movement           *MovementStats    `json:"movement,omitempty"`      // only with movement tracking
 }
 
 type PlayerMatchStats struct {
-	Username           string  `json:"username"`
-	TeamIndex          int     `json:"-"`
-	Rounds             int     `json:"rounds"`
-	Kills              int     `json:"kills"`
-	Deaths             int     `json:"deaths"`
-	Assists            int     `json:"assists"`
-	Headshots          int     `json:"headshots"`
-	HeadshotPercentage float64 `json:"headshotPercentage"`
+	Username           string            `json:"username"`
+	TeamIndex          int               `json:"-"`
+	Rounds             int               `json:"rounds"`
+	Kills              int               `json:"kills"`
+	TeamKills          int               `json:"teamKills"`
+	Deaths             int               `json:"deaths"`
+	Assists            int               `json:"assists"`
+	Headshots          int               `json:"headshots"`
+	HeadshotPercentage float64           `json:"headshotPercentage"`
+	Revived            int               `json:"revived,omitempty"`   // times the player was revived, not by them (revivers are not recorded)
+	SlotKills          map[KillCause]int `json:"slotKills,omitempty"` // kills per weapon slot, see KillCause
+	AreaSecures        int               `json:"areaSecures,omitempty"`
+	HostagePickups     int               `json:"hostagePickups,omitempty"`
+	HostageExtractions int               `json:"hostageExtractions,omitempty"`
+	AbilitiesUsed      int               `json:"abilitiesUsed,omitempty"` // operator ability charges
+	Movement           *MovementStats    `json:"movement,omitempty"`      // only with movement tracking
 }
 
-// OpeningKill returns the first player to kill.
+// OpeningKill returns the first player to kill an opponent.
 func (r *Reader) OpeningKill() MatchUpdate {
 	for _, a := range r.MatchFeedback {
-		if a.Type == Kill {
+		if a.countedKill() {
 			return a
 		}
 	}
 	return MatchUpdate{}
 }
 
-// OpeningDeath returns the first player to die (KILL or DEATH activity).
+// OpeningDeath returns the first player to die (KILL, DEATH or BLEEDOUT activity).
 func (r *Reader) OpeningDeath() MatchUpdate {
 	for _, a := range r.MatchFeedback {
-		if a.Type == Kill || a.Type == Death {
+		if _, died := a.victim(); died {
 			return a
 		}
 	}
@@ -62,7 +78,7 @@ func (r *Reader) Trades() [][]MatchUpdate {
 func (r *Reader) KillsAndDeaths() []MatchUpdate {
 	MatchFeedback := make([]MatchUpdate, 0)
 	for _, a := range r.MatchFeedback {
-		if a.Type == Kill || a.Type == Death {
+		if a.Type == Kill || a.Type == Death || a.Type == BleedOut {
 			MatchFeedback = append(MatchFeedback, a)
 		}
 	}
@@ -101,16 +117,37 @@ func (r *Reader) PlayerStats() []PlayerRoundStats {
 	for _, a := range r.MatchFeedback {
 		i := index[a.Username]
 		if a.Type == Kill {
-			stats[i].Kills += 1
-			if *a.Headshot {
-				stats[i].Headshots += 1
+			if a.TeamKill {
+				stats[i].TeamKills += 1
+			} else if !a.Suicide {
+				stats[i].Kills += 1
+				if *a.Headshot {
+					stats[i].Headshots += 1
+				}
+				stats[i].HeadshotPercentage = headshotPercentage(stats[i].Headshots, stats[i].Kills)
+				if a.Cause != "" {
+					if stats[i].SlotKills == nil {
+						stats[i].SlotKills = make(map[KillCause]int)
+					}
+					stats[i].SlotKills[a.Cause]++
+				}
 			}
-			stats[i].HeadshotPercentage = headshotPercentage(stats[i].Headshots, stats[i].Kills)
 			stats[index[a.Target]].Died = true
 			lastDeath = index[a.Target]
-		} else if a.Type == Death {
+		} else if a.Type == Death || a.Type == BleedOut {
 			stats[i].Died = true
 			lastDeath = i
+		} else if a.Type == Revive {
+			stats[i].Revived++
+		} else if a.Username != "" {
+			switch a.Type {
+			case AreaSecured:
+				stats[i].AreaSecures++
+			case HostagePickup:
+				stats[i].HostagePickups++
+			case HostageExtracted:
+				stats[i].HostageExtractions++
+			}
 		}
 	}
 	// Calculates 1vX
@@ -141,7 +178,7 @@ func (r *Reader) PlayerStats() []PlayerRoundStats {
 		for _, a := range r.MatchFeedback {
 			if a.Type == Kill && stats[index[a.Target]].TeamIndex == winningTeamIndex {
 				teamLeft--
-			} else if a.Type == Death && stats[index[a.Username]].TeamIndex == winningTeamIndex {
+			} else if (a.Type == Death || a.Type == BleedOut) && stats[index[a.Username]].TeamIndex == winningTeamIndex {
 				teamLeft--
 			} else if a.Type == PlayerLeave && stats[index[a.Username]].TeamIndex == winningTeamIndex {
 				teamLeft--
@@ -149,7 +186,7 @@ func (r *Reader) PlayerStats() []PlayerRoundStats {
 			if a.Username != username {
 				continue
 			}
-			if a.Type == Kill && teamLeft < 2 {
+			if a.countedKill() && teamLeft < 2 {
 				oneVx++
 			}
 		}
@@ -160,6 +197,19 @@ func (r *Reader) PlayerStats() []PlayerRoundStats {
 		}
 		stats[lastWinnerStanding].OneVx = oneVx
 	}
+	if len(r.rawPositions) > 0 {
+		movement := r.MovementStats()
+		for i := range stats {
+			if m, ok := movement[stats[i].Username]; ok {
+				stats[i].Movement = &m
+			}
+		}
+	}
+	for _, a := range r.AbilityUpdates {
+		if i, ok := index[a.Username]; ok {
+			stats[i].AbilitiesUsed++
+		}
+	}
 	return stats
 }
 
@@ -178,12 +228,30 @@ func (m *MatchReader) PlayerStats() []PlayerMatchStats {
 			i = index[p.Username]
 			stats[i].Rounds += 1
 			stats[i].Kills += p.Kills
+			stats[i].TeamKills += p.TeamKills
 			if p.Died {
 				stats[i].Deaths += 1
 			}
 			stats[i].Assists += p.Assists
 			stats[i].Headshots += p.Headshots
+			stats[i].Revived += p.Revived
+			stats[i].AreaSecures += p.AreaSecures
+			stats[i].HostagePickups += p.HostagePickups
+			stats[i].HostageExtractions += p.HostageExtractions
 			stats[i].HeadshotPercentage = headshotPercentage(stats[i].Headshots, stats[i].Kills)
+			for cause, n := range p.SlotKills {
+				if stats[i].SlotKills == nil {
+					stats[i].SlotKills = make(map[KillCause]int)
+				}
+				stats[i].SlotKills[cause] += n
+			}
+			stats[i].AbilitiesUsed += p.AbilitiesUsed
+			if p.Movement != nil {
+				if stats[i].Movement == nil {
+					stats[i].Movement = &MovementStats{}
+				}
+				stats[i].Movement.add(*p.Movement)
+			}
 		}
 	}
 	return stats
@@ -0,0 +1,256 @@
+package dissect
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	streamChunkSize = 64 * 1024 // bytes decompressed per fill
+	streamLookBack  = 4 * 1024  // bytes kept before the oldest pending match (PeekBack, entity IDs)
+	streamLookAhead = 16 * 1024 // bytes buffered past a match before its listeners run
+)
+
+// NewStreamReader validates the dissect header like NewReader,
+// but decompresses the replay body incrementally during Read.
+// Listeners run as each zstd section is decompressed and only a
+// bounded window of the decompressed replay is kept in memory.
+func NewStreamReader(in io.Reader) (r *Reader, err error) {
+	if r, err = newStreamReader(in); err != nil {
+		return r, err
+	}
+	r.listenDefaults()
+	return r, nil
+}
+
+// newStreamReader is NewStreamReader without the built-in listeners.
+func newStreamReader(in io.Reader) (r *Reader, err error) {
+	br := bufio.NewReader(in)
+	chunkedCompression, err := testFileCompression(br)
+	if err != nil {
+		return r, err
+	}
+	log.Debug().Bool("chunkedCompression (>=Y8S4)", chunkedCompression).Bool("stream", true).Send()
+	r = newReader()
+	if chunkedCompression {
+		// The header is stored uncompressed before the zstd sections.
+		r.src = br
+	} else {
+		zstdReader, err := zstd.NewReader(br)
+		if err != nil {
+			return r, err
+		}
+		r.src = &zstdSource{zstdReader}
+	}
+	if err = r.readHeaderMagic(); err != nil {
+		return r, err
+	}
+	h, err := r.readHeader()
+	r.Header = h
+	if err != nil {
+		return r, err
+	}
+	if chunkedCompression {
+		// Hand the buffered remainder back to the section decompressor.
+		rest := bytes.Clone(r.b[r.offset:])
+		r.src = newChunkedSource(io.MultiReader(bytes.NewReader(rest), br))
+		r.srcErr = nil
+		r.b = nil
+		r.offset = 0
+	}
+	log.Debug().Str("season", r.Header.GameVersion).Int("code", r.Header.CodeVersion).Send()
+	return r, nil
+}
+
+// fill decompresses the next block of the stream into the window.
+func (r *Reader) fill() error {
+	if r.src == nil {
+		return io.EOF
+	}
+	if r.srcErr != nil {
+		return r.srcErr
+	}
+	if r.ctx != nil {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	n := len(r.b)
+	if cap(r.b)-n < streamChunkSize {
+		grown := make([]byte, n, 2*cap(r.b)+streamChunkSize)
+		copy(grown, r.b)
+		r.b = grown
+	}
+	read, err := r.src.Read(r.b[n : n+streamChunkSize])
+	r.b = r.b[:n+read]
+	if err != nil {
+		r.srcErr = err
+		if read > 0 {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// compact drops window bytes before keep, retaining streamLookBack bytes.
+// A new buffer is allocated so slices previously returned by Bytes stay valid.
+func (r *Reader) compact(keep int) {
+	keep -= streamLookBack
+	if keep < streamChunkSize || r.Retain {
+		return
+	}
+	kept := make([]byte, len(r.b)-keep, len(r.b)-keep+streamChunkSize)
+	copy(kept, r.b[keep:])
+	r.b = kept
+	r.offset -= keep
+	r.discarded += keep
+}
+
+// position returns the absolute offset in the decompressed replay.
+func (r *Reader) position() int {
+	return r.discarded + r.offset
+}
+
+// readStream is the streaming equivalent of Read. Matches are collected
+// as data is decompressed and dispatched in order once enough bytes
+// after them are buffered.
+func (r *Reader) readStream(ctx context.Context) (err error) {
+	a := newAutomaton(r.queries)
+	state := int32(0)
+	pending := make([]match, 0)
+	scanned := r.position()
+	eof := false
+	for {
+		if from := scanned - r.discarded; from < len(r.b) {
+			state, pending = a.scan(r.b[from:], state, scanned, 0, pending)
+			scanned = r.discarded + len(r.b)
+		}
+		for len(pending) > 0 {
+			m := pending[0]
+			if !eof && m.offset-r.discarded+streamLookAhead >= len(r.b) {
+				break
+			}
+			pending = pending[1:]
+			if err = r.dispatch(match{m.offset - r.discarded, m.listenerIndex}); err != nil {
+				return
+			}
+			if err = r.checkpoint(ctx, 0, false); err != nil {
+				return
+			}
+			if r.readPartial && r.playersRead >= 10 {
+				return r.finish()
+			}
+		}
+		// listeners may have buffered more data while reading ahead
+		if scanned-r.discarded < len(r.b) {
+			continue
+		}
+		if eof {
+			break
+		}
+		keep := scanned
+		if len(pending) > 0 {
+			keep = pending[0].offset
+		}
+		r.compact(keep - r.discarded)
+		if err = r.checkpoint(ctx, 0, true); err != nil {
+			return
+		}
+		if fillErr := r.fill(); fillErr != nil {
+			if !errors.Is(fillErr, io.EOF) {
+				return fillErr
+			}
+			eof = true
+		}
+	}
+	log.Debug().Int("bytes", r.discarded+len(r.b)).Msg("stream read")
+	if err = r.checkpoint(ctx, r.markersDispatched, true); err != nil {
+		return
+	}
+	return r.finish()
+}
+
+// zstdSource reads a non-chunked replay, treating trailing
+// uncompressed data as the end of the stream.
+type zstdSource struct {
+	*zstd.Decoder
+}
+
+func (s *zstdSource) Read(p []byte) (int, error) {
+	n, err := s.Decoder.Read(p)
+	if errors.Is(err, zstd.ErrMagicMismatch) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// chunkedSource decompresses the zstd sections of a chunked (>=Y8S4)
+// replay one after another, skipping the data between sections.
+type chunkedSource struct {
+	in          *bufio.Reader
+	zstd        *zstd.Decoder
+	open        bool
+	sections    int
+	maxSections int // 0 reads every section
+}
+
+func newChunkedSource(in io.Reader) *chunkedSource {
+	zstdReader, _ := zstd.NewReader(nil)
+	return &chunkedSource{in: bufio.NewReader(in), zstd: zstdReader}
+}
+
+func (s *chunkedSource) Read(p []byte) (int, error) {
+	for {
+		if !s.open {
+			if err := s.next(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := s.zstd.Read(p)
+		if err != nil {
+			s.open = false
+			if !errors.Is(err, io.EOF) && !errors.Is(err, zstd.ErrMagicMismatch) {
+				return n, err
+			}
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// next seeks to the next zstd section and resets the decompressor to it.
+func (s *chunkedSource) next() error {
+	if s.maxSections > 0 && s.sections >= s.maxSections {
+		return io.EOF
+	}
+	zstdMagic := []byte{0x28, 0xB5, 0x2F, 0xFD}
+	patternIndex := 0
+	for patternIndex != 4 {
+		b, err := s.in.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Debug().Int("zstd_sections", s.sections).Send()
+			}
+			return err
+		}
+		if b == zstdMagic[patternIndex] {
+			patternIndex++
+		} else {
+			patternIndex = 0
+		}
+	}
+	s.sections++
+	if err := s.zstd.Reset(io.MultiReader(bytes.NewReader(zstdMagic), s.in)); err != nil {
+		return err
+	}
+	s.open = true
+	return nil
+}
@@ -0,0 +1,44 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestReader_AbilityDeploys(t *testing.T) {
+	// Alpha's third ammo entity has few charges, so it is an ability launcher
+	const primary, secondary, ability = 0x1001, 0x1002, 0x1003
+	r := objectiveReplay(t, dissect.Bomb, dissect.Y9S1,
+		timePacket(180),
+		ammoPacket(primary, 31, 181, true),
+		ammoPacket(secondary, 13, 65, true),
+		ammoPacket(ability, 3, 3, true),
+		timePacket(170),
+		ammoPacket(ability, 2, 2, false),
+		timePacket(160),
+		ammoPacket(ability, 0, 0, false),
+	)
+	deployed := 0
+	dissect.Subscribe(r, func(e dissect.AbilityEvent) error {
+		deployed++
+		return nil
+	})
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	if deployed != 3 || len(r.AbilityUpdates) != 3 {
+		t.Fatalf("got %d ability events and %d updates, want 3", deployed, len(r.AbilityUpdates))
+	}
+	for i, want := range []float64{170, 160, 160} {
+		a := r.AbilityUpdates[i]
+		if a.Username != "Alpha" || a.Operator != dissect.Ash || a.EntityID != ability || a.TimeInSeconds != want {
+			t.Errorf("update %d: got %+v, want Alpha's ability used at %v", i, a, want)
+		}
+	}
+	for _, s := range r.PlayerStats() {
+		if s.Username == "Alpha" && s.AbilitiesUsed != 3 {
+			t.Errorf("got %d abilities used by Alpha, want 3", s.AbilitiesUsed)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+const recordingProfileID = "1f63af29-7ebe-48e7-b570-e820632d9565"
+
+// playerProps returns header props with two players and a recording profile id.
+func playerProps() [][2]string {
+	props := [][2]string{{"recordingprofileid", recordingProfileID}}
+	props = append(props, replayProps[:len(replayProps)-1]...)
+	for i, name := range []string{"redraskal", "Ash.Main"} {
+		props = append(props,
+			[2]string{"playerid", "1234"},
+			[2]string{"playername", name},
+			[2]string{"team", string(rune('0' + i))},
+			[2]string{"heroname", "0"},
+			[2]string{"alliance", "0"},
+			[2]string{"roleimage", "0"},
+			[2]string{"rolename", ""},
+			[2]string{"roleportrait", "0"},
+		)
+	}
+	props = append(props, [2]string{"playlistcategory", "0"})
+	return append(props, replayProps[len(replayProps)-1])
+}
+
+// nameBody contains each name after a test marker and the recording profile id.
+func nameBody(names ...string) []byte {
+	body := clockBody(180)
+	for _, name := range names {
+		body = append(body, testMarker...)
+		body = append(body, byte(len(name)))
+		body = append(body, name...)
+		body = append(body, bytes.Repeat([]byte{0x10}, 32)...)
+	}
+	body = append(body, byte(len(recordingProfileID)))
+	body = append(body, recordingProfileID...)
+	return append(body, bytes.Repeat([]byte{0x10}, 32)...)
+}
+
+func readNames(t *testing.T, replay []byte) (*dissect.Reader, []string) {
+	t.Helper()
+	r, err := dissect.NewReader(bytes.NewReader(replay))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	names := make([]string, 0)
+	r.Listen(testMarker, func(r *dissect.Reader) error {
+		name, err := r.String()
+		names = append(names, name)
+		return err
+	})
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	return r, names
+}
+
+func TestAnonymize(t *testing.T) {
+	for _, chunked := range []bool{true, false} {
+		sections := [][]byte{nameBody("redraskal"), nameBody("Ash.Main", "redraskal")}
+		replay := buildReplay(t, playerProps(), sections, chunked)
+		a := dissect.NewAnonymizer()
+		out := bytes.Buffer{}
+		if err := a.Anonymize(bytes.NewReader(replay), &out); err != nil {
+			t.Fatalf("chunked=%v: Anonymize(): expected no error, got %v", chunked, err)
+		}
+		pseudonyms := a.Pseudonyms()
+		if pseudonyms["redraskal"] != "Player001" || pseudonyms["Ash.Main"] != "Player02" {
+			t.Errorf("unexpected pseudonyms %v", pseudonyms)
+		}
+		anonymized := out.Bytes()
+		if bytes.HasPrefix(anonymized, []byte("dissect")) != chunked {
+			t.Errorf("chunked=%v: compression layout changed", chunked)
+		}
+		for _, s := range []string{"redraskal", "Ash.Main", recordingProfileID} {
+			if bytes.Contains(anonymized, []byte(s)) {
+				t.Errorf("chunked=%v: %q left in compressed replay", chunked, s)
+			}
+		}
+		r, names := readNames(t, anonymized)
+		if len(names) != 3 || names[0] != "Player001" || names[1] != "Player02" || names[2] != "Player001" {
+			t.Errorf("chunked=%v: got body names %v", chunked, names)
+		}
+		if r.Header.Players[0].Username != "Player001" || r.Header.Players[1].Username != "Player02" {
+			t.Errorf("chunked=%v: got header players %+v", chunked, r.Header.Players)
+		}
+		if r.Header.RecordingProfileID != pseudonyms[recordingProfileID] || len(r.Header.RecordingProfileID) != 36 {
+			t.Errorf("chunked=%v: got recording profile id %s", chunked, r.Header.RecordingProfileID)
+		}
+		out.Reset()
+		if err := a.Anonymize(bytes.NewReader(anonymized), &out); err != nil {
+			t.Fatalf("chunked=%v: Anonymize(): expected no error, got %v", chunked, err)
+		}
+		if _, names = readNames(t, out.Bytes()); names[0] != "Player001" {
+			t.Errorf("chunked=%v: pseudonyms are not stable, got %v", chunked, names)
+		}
+	}
+}
+
+func TestAnonymize_BinaryFields(t *testing.T) {
+	// the bytes of the name are also the Y coordinate of every position, 12.08
+	const name = "AAAA"
+	y := math.Float32frombits(binary.LittleEndian.Uint32([]byte(name)))
+	attackers := []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}
+	defenders := []string{name, "Target2", "Target3", "Target4", "Target5"}
+	packets := make([][]byte, 0)
+	for i, username := range append(attackers, defenders...) {
+		op := dissect.Ash
+		if i >= len(attackers) {
+			op = dissect.Rook
+		}
+		packets = append(packets, playerPacket(username, op, byte(i+1)))
+	}
+	packets = append(packets, timePacket(180))
+	for i := range 50 {
+		packets = append(packets, positionPacket(0x1010, 0xB8, 0x01, float32(min(i, 39))*0.5, y, 1, 10))
+	}
+	packets = append(packets, timePacket(179), killPacket("Alpha", name, killTypeKill, false))
+	replay := buildReplay(t, teamProps(attackers, defenders), [][]byte{join(packets...)}, true)
+	read := func(replay []byte) *dissect.Reader {
+		r, err := dissect.NewReader(bytes.NewReader(replay))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		r.EnableMovementTracking(0)
+		if err = r.Read(); !dissect.Ok(err) {
+			t.Fatalf("Read(): expected no error, got %v", err)
+		}
+		return r
+	}
+	a := dissect.NewAnonymizer()
+	out := bytes.Buffer{}
+	if err := a.Anonymize(bytes.NewReader(replay), &out); err != nil {
+		t.Fatalf("Anonymize(): expected no error, got %v", err)
+	}
+	before, after := read(replay), read(out.Bytes())
+	pseudonym := a.Pseudonyms()[name]
+	if i := slices.IndexFunc(after.MatchFeedback, func(u dissect.MatchUpdate) bool { return u.Type == dissect.Kill }); i < 0 || after.MatchFeedback[i].Target != pseudonym {
+		t.Errorf("got updates %v, want a kill of %s", after.MatchFeedback, pseudonym)
+	}
+	if len(before.MatchFeedback) != len(after.MatchFeedback) {
+		t.Fatalf("got %d updates, want %d", len(after.MatchFeedback), len(before.MatchFeedback))
+	}
+	for i, u := range after.MatchFeedback {
+		if u.Type != before.MatchFeedback[i].Type || u.TimeInSeconds != before.MatchFeedback[i].TimeInSeconds {
+			t.Errorf("update %d: got %v, want %v", i, u, before.MatchFeedback[i])
+		}
+	}
+	positions := func(r *dissect.Reader) [][3]float32 {
+		p := make([][3]float32, 0)
+		for _, m := range r.GetMovementData() {
+			for _, pos := range m.Positions {
+				p = append(p, [3]float32{pos.X, pos.Y, pos.Z})
+			}
+		}
+		return p
+	}
+	if got, want := positions(after), positions(before); len(want) != 50 || !slices.Equal(got, want) {
+		t.Errorf("got positions %v, want %v", got, want)
+	}
+}
+
+// decompressAll returns replay with every zstd frame decompressed in place.
+func decompressAll(t *testing.T, replay []byte) []byte {
+	t.Helper()
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decoder.Close()
+	magic := []byte{0x28, 0xB5, 0x2F, 0xFD}
+	data := make([]byte, 0)
+	for {
+		i := bytes.Index(replay, magic)
+		if i < 0 {
+			return append(data, replay...)
+		}
+		data = append(data, replay[:i]...)
+		if err = decoder.Reset(bytes.NewReader(replay[i:])); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(decoder) // stops at the data after the frame
+		data = append(data, b...)
+		replay = replay[i+len(magic):]
+	}
+}
+
+func TestAnonymize_Messages(t *testing.T) {
+	replay := objectiveReplayData(t, dissect.Bomb, dissect.Y8S4,
+		timePacket(120),
+		messagePacket("Target1 left the game"),
+		messagePacket("Alpha found the bombs"),
+	)
+	a := dissect.NewAnonymizer()
+	out := bytes.Buffer{}
+	if err := a.Anonymize(bytes.NewReader(replay), &out); err != nil {
+		t.Fatalf("Anonymize(): expected no error, got %v", err)
+	}
+	pseudonyms := a.Pseudonyms()
+	r, err := dissect.NewReader(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	data := decompressAll(t, out.Bytes())
+	for _, p := range r.Header.Players {
+		if _, ok := pseudonyms[p.Username]; ok {
+			t.Errorf("username %s was not replaced", p.Username)
+		}
+	}
+	for name := range pseudonyms {
+		if len(name) > 1 && bytes.Contains(data, []byte(name)) {
+			t.Errorf("%q left in the anonymized replay", name)
+		}
+	}
+	if !bytes.Contains(data, []byte(pseudonyms["Target1"]+" left the game")) {
+		t.Errorf("leave message of %s not found", pseudonyms["Target1"])
+	}
+	leave := slices.IndexFunc(r.MatchFeedback, func(u dissect.MatchUpdate) bool { return u.Type == dissect.PlayerLeave })
+	if leave < 0 || r.MatchFeedback[leave].Username != pseudonyms["Target1"] {
+		t.Errorf("got updates %v, want %s to leave", r.MatchFeedback, pseudonyms["Target1"])
+	}
+	if r.Header.RecordingPlayerID == 1 || r.Header.Players[0].ID == 1234 {
+		t.Errorf("player ids were not replaced: recording %d, player %d", r.Header.RecordingPlayerID, r.Header.Players[0].ID)
+	}
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestReader_PositionTimestamps(t *testing.T) {
+	const drone = 0x9001
+	pos := func(x float32) []byte {
+		return positionPacket(drone, 0xB8, 0x01, x, 1, 1, 0)
+	}
+	r := objectiveReplay(t, dissect.Bomb, dissect.Y9S1,
+		timePacket(2), // prep phase
+		pos(1),
+		pos(2),
+		timePacket(1),
+		pos(3),
+		timePacket(180), // action phase
+		pos(4),
+		pos(5),
+		timePacket(179),
+		pos(6),
+	)
+	r.EnableMovementTracking(0)
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	entities := r.GetEntityMovements()
+	if len(entities) != 1 {
+		t.Fatalf("got %d entities, want 1", len(entities))
+	}
+	want := []struct{ time, elapsed float64 }{
+		{2, 0},
+		{1.5, 0.5},
+		{1, 1},
+		{180, 1},
+		{179.5, 1.5},
+		{179, 2},
+	}
+	positions := entities[0].Positions
+	if len(positions) != len(want) {
+		t.Fatalf("got %d positions, want %d", len(positions), len(want))
+	}
+	for i, w := range want {
+		p := positions[i]
+		if p.TimeInSeconds != w.time || p.ElapsedSeconds != w.elapsed {
+			t.Errorf("position %d: got time %v elapsed %v, want time %v elapsed %v", i, p.TimeInSeconds, p.ElapsedSeconds, w.time, w.elapsed)
+		}
+	}
+}
+
+func TestReader_PositionTimestampsClockDrop(t *testing.T) {
+	const drone = 0x9001
+	pos := func(x float32) []byte {
+		return positionPacket(drone, 0xB8, 0x01, x, 1, 1, 0)
+	}
+	r := objectiveReplay(t, dissect.Bomb, dissect.Y9S1,
+		timePacket(60),
+		pos(1),
+		timePacket(59),
+		pos(2),
+		pos(3),
+		timePacket(45), // the defuser timer replaces the round clock
+		pos(4),
+		timePacket(44),
+		pos(5),
+	)
+	r.EnableMovementTracking(0)
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	entities := r.GetEntityMovements()
+	if len(entities) != 1 {
+		t.Fatalf("got %d entities, want 1", len(entities))
+	}
+	want := []struct{ time, elapsed float64 }{
+		{60, 0},
+		{59, 1},
+		{59, 1},
+		{45, 1},
+		{44, 2},
+	}
+	positions := entities[0].Positions
+	if len(positions) != len(want) {
+		t.Fatalf("got %d positions, want %d", len(positions), len(want))
+	}
+	for i, w := range want {
+		p := positions[i]
+		if p.TimeInSeconds != w.time || p.ElapsedSeconds != w.elapsed {
+			t.Errorf("position %d: got time %v elapsed %v, want time %v elapsed %v", i, p.TimeInSeconds, p.ElapsedSeconds, w.time, w.elapsed)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestReader_DBNOLifecycle(t *testing.T) {
+	body := join(
+		timePacket(170),
+		killPacket("Alpha", "Target1", killTypeDBNO, false),
+		countdown(169, 165),
+		killPacket("Target1", "Bravo", killTypeDBNO, false), // e.g. by C4 while downed, not a revive
+		countdown(164, 160),
+		killPacket("Finisher", "Bravo", killTypeKill, false),
+		killPacket("Alpha", "Target2", killTypeDBNO, false),
+		countdown(159, 150),
+		killPacket("Alpha", "Target2", killTypeDBNO, false), // revives are only inferred from the bleed-out window
+		countdown(149, 140),
+		killPacket("", "Target2", killTypeDeath, false),
+	)
+	props := teamProps([]string{"Alpha", "Bravo"}, []string{"Target1", "Target2"})
+	r, err := dissect.NewReader(bytes.NewReader(buildReplay(t, props, [][]byte{body}, true)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	revives := 0
+	dissect.Subscribe(r, func(e dissect.ReviveEvent) error {
+		revives++
+		return nil
+	})
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	want := []struct {
+		typ      dissect.MatchUpdateType
+		username string
+		target   string
+		down     int // index of the linked DBNO, -1 for none
+	}{
+		{dissect.DBNO, "Alpha", "Target1", -1},
+		{dissect.DBNO, "Target1", "Bravo", -1},
+		{dissect.Kill, "Target1", "Bravo", 1},
+		{dissect.DBNO, "Alpha", "Target2", -1},
+		{dissect.DBNO, "Alpha", "Target2", -1},
+		{dissect.BleedOut, "Target2", "", 4},
+		{dissect.RoundEnd, "", "", -1},
+	}
+	if len(r.MatchFeedback) != len(want) {
+		t.Fatalf("got %d match updates, want %d: %+v", len(r.MatchFeedback), len(want), r.MatchFeedback)
+	}
+	for i, w := range want {
+		u := r.MatchFeedback[i]
+		if u.Type != w.typ || u.Username != w.username || u.Target != w.target {
+			t.Errorf("update %d: got %v %s -> %s, want %v %s -> %s", i, u.Type, u.Username, u.Target, w.typ, w.username, w.target)
+		}
+		if w.down < 0 {
+			if u.Down != nil {
+				t.Errorf("update %d: unexpected down %+v", i, u.Down)
+			}
+			continue
+		}
+		if u.Down == nil || u.Down.Index != w.down || u.Down.Username != r.MatchFeedback[w.down].Username {
+			t.Errorf("update %d: got down %+v, want DBNO %d", i, u.Down, w.down)
+		}
+	}
+	if finisher := r.MatchFeedback[2].Finisher; finisher != "Finisher" {
+		t.Errorf("got finisher %q, want Finisher", finisher)
+	}
+	if revives != 0 {
+		t.Errorf("got %d revive events, want 0", revives)
+	}
+	if _, downed := r.GetDowner("Target1"); !downed {
+		t.Error("Target1 is not in DBNO state after attacking while downed")
+	}
+	if _, downed := r.GetDowner("Target2"); downed {
+		t.Error("Target2 is still in DBNO state after bleeding out")
+	}
+}
+
+func TestReader_DBNOExpiry(t *testing.T) {
+	r := objectiveReplay(t, dissect.Bomb, dissect.Y9S1,
+		timePacket(170),
+		killPacket("Alpha", "Target1", killTypeDBNO, false),
+		killPacket("Alpha", "Target1", killTypeDBNO, false), // duplicate packet
+		countdown(169, 150),
+		killPacket("Bravo", "Target2", killTypeDBNO, false),
+		countdown(149, 139), // Target1 would have bled out, so was revived
+		countdown(138, 120),
+		killPacket("Bravo", "Target1", killTypeKill, false),
+	)
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	want := []struct {
+		typ      dissect.MatchUpdateType
+		username string
+		target   string
+		time     string
+	}{
+		{dissect.DBNO, "Alpha", "Target1", "2:50"},
+		{dissect.DBNO, "Bravo", "Target2", "2:30"},
+		{dissect.Revive, "Target1", "", "2:19"},
+		{dissect.Kill, "Bravo", "Target1", "2:00"},
+		{dissect.RoundEnd, "", "", ""}, // the clock did not run out
+	}
+	if len(r.MatchFeedback) != len(want) {
+		t.Fatalf("got %d match updates, want %d: %+v", len(r.MatchFeedback), len(want), r.MatchFeedback)
+	}
+	for i, w := range want {
+		u := r.MatchFeedback[i]
+		if u.Type != w.typ || u.Username != w.username || u.Target != w.target || u.Time != w.time {
+			t.Errorf("update %d: got %v %s -> %s at %s, want %v %s -> %s at %s",
+				i, u.Type, u.Username, u.Target, u.Time, w.typ, w.username, w.target, w.time)
+		}
+	}
+	if kill := r.MatchFeedback[3]; kill.Down != nil || kill.Finisher != "" {
+		t.Errorf("kill of a revived player linked to an earlier DBNO: %+v", kill)
+	}
+	if _, downed := r.GetDowner("Target2"); !downed {
+		t.Error("Target2 left DBNO state within the bleed-out window")
+	}
+	for _, s := range r.PlayerStats() {
+		want := 0
+		if s.Username == "Target1" {
+			want = 1
+		}
+		if s.Revived != want {
+			t.Errorf("%s: got revived %d, want %d", s.Username, s.Revived, want)
+		}
+		if s.Username == "Bravo" && s.Kills != 1 {
+			t.Errorf("Bravo: got %d kills, want 1", s.Kills)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+var errOddMarker = errors.New("odd marker")
+
+// readOddMarkers reads a replay whose listener fails on every odd test marker.
+func readOddMarkers(t *testing.T, lenient bool) (*dissect.Reader, []uint32, int, error) {
+	t.Helper()
+	sections, markers := markerBody(2, 16*1024)
+	replay := buildReplay(t, replayProps, sections, true)
+	r, err := dissect.NewReader(bytes.NewReader(replay))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r.Lenient = lenient
+	got := make([]uint32, 0)
+	r.Listen(testMarker, func(r *dissect.Reader) error {
+		b, err := r.Bytes(4)
+		if err != nil {
+			return err
+		}
+		n := binary.LittleEndian.Uint32(b)
+		if n%2 == 1 {
+			return errOddMarker
+		}
+		got = append(got, n)
+		return nil
+	})
+	return r, got, markers, r.Read()
+}
+
+func TestReader_Diagnostics(t *testing.T) {
+	r, got, _, err := readOddMarkers(t, false)
+	if !errors.Is(err, errOddMarker) {
+		t.Fatalf("Read(): expected listener error, got %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d markers before the error, want 1", len(got))
+	}
+	if len(r.Diagnostics) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(r.Diagnostics))
+	}
+	d := r.Diagnostics[0]
+	if d.Severity != dissect.SeverityError || d.Message != errOddMarker.Error() {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+	if d.Listener == "" || d.Offset == 0 {
+		t.Errorf("diagnostic missing listener or offset: %+v", d)
+	}
+}
+
+func TestReader_Lenient(t *testing.T) {
+	r, got, markers, err := readOddMarkers(t, true)
+	if !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	if len(got) != (markers+1)/2 {
+		t.Errorf("got %d markers, want %d", len(got), (markers+1)/2)
+	}
+	if len(r.Diagnostics) != markers/2 {
+		t.Errorf("got %d diagnostics, want %d", len(r.Diagnostics), markers/2)
+	}
+	for i := 1; i < len(r.Diagnostics); i++ {
+		if r.Diagnostics[i].Offset <= r.Diagnostics[i-1].Offset {
+			t.Errorf("diagnostics out of order at %d", i)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// positionPacket returns a 607385fe position packet of the entity. Player IDs
+// 5-14 map to the header players; other IDs are non-player entities.
+func positionPacket(entity uint32, typeFirst, typeSecond byte, x, y, z float32, playerID uint32) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, entity)
+	b = append(b, 0x00, 0x00, 0x60, 0x73, 0x85, 0xfe, typeFirst, typeSecond)
+	for _, f := range []float32{x, y, z} {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+	}
+	var post []byte
+	switch typeSecond {
+	case 0x01, 0x02:
+		post = make([]byte, 8)
+		binary.LittleEndian.PutUint32(post[4:], playerID)
+	case 0x03:
+		post = make([]byte, 62)
+		binary.LittleEndian.PutUint32(post[20:], playerID)
+	default:
+		post = make([]byte, 64)
+	}
+	return append(b, post...)
+}
+
+func TestReader_EntityMovements(t *testing.T) {
+	const drone, throwable, gadget, noise, invalid = 0x9001, 0x9002, 0x9003, 0x9004, 0x9005
+	packets := [][]byte{timePacket(180)}
+	// Target1 walks to (1, 1) and Target2 to (20, 20), long enough to be tracked
+	for i := range 31 {
+		packets = append(packets,
+			positionPacket(0x100A, 0xB8, 0x01, float32(i-30)/10+1, 1, 1, 10),
+			positionPacket(0x100B, 0xB8, 0x01, 20, float32(i-30)/10+20, 1, 11),
+		)
+	}
+	packets = append(packets,
+		positionPacket(drone, 0xB8, 0x01, 1.5, 1, 1, 0),
+		positionPacket(drone, 0xB8, 0x01, 3, 1, 1, 0),
+		positionPacket(gadget, 0xB8, 0x02, 20, 21, 1, 0),
+		positionPacket(gadget, 0xB8, 0x02, 20.1, 21, 1, 0),
+		positionPacket(throwable, 0xB8, 0x3F, 20.5, 20, 1, 0),
+		positionPacket(throwable, 0xB8, 0x3F, 21, 20, 1.5, 0),
+		positionPacket(throwable, 0xB8, 0x3F, 22, 20, 1, 0),
+		positionPacket(noise, 0xB8, 0x01, 40, 40, 1, 0),
+	)
+	for range 3 { // enough sightings to be reported, but z is NaN
+		packets = append(packets, positionPacket(invalid, 0xB8, 0x3F, 30, 30, float32(math.NaN()), 0))
+	}
+	for i := range 90 {
+		packets = append(packets, positionPacket(0x100A, 0xB8, 0x01, 1+float32(i)/100, 1, 1, 10))
+		if (i+1)%10 == 0 {
+			packets = append(packets, timePacket(uint32(180-(i+1)/10)))
+		}
+	}
+	packets = append(packets,
+		positionPacket(drone, 0xB8, 0x01, 5, 1, 1, 0),
+		positionPacket(drone, 0xB8, 0x01, 7, 1, 1.2, 0),
+		positionPacket(gadget, 0xB8, 0x02, 20, 21, 1, 0),
+	)
+	r := objectiveReplay(t, dissect.Bomb, dissect.Y9S1, packets...)
+	r.EnableMovementTracking(0)
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	want := []struct {
+		id         uint32
+		kind       dissect.EntityKind
+		packetType string
+		owner      string
+		positions  int
+	}{
+		{drone, dissect.EntityDrone, "B801", "Target1", 4},
+		{gadget, dissect.EntityGadget, "B802", "Target2", 3},
+		{throwable, dissect.EntityThrowable, "B83F", "Target2", 3},
+	}
+	entities := r.GetEntityMovements()
+	if len(entities) != len(want) {
+		t.Fatalf("got %d entities, want %d: %+v", len(entities), len(want), entities)
+	}
+	for i, w := range want {
+		e := entities[i]
+		if e.EntityID != w.id || e.Kind != w.kind || e.PacketType != w.packetType || e.Owner != w.owner || len(e.Positions) != w.positions {
+			t.Errorf("entity %d: got %X %s %s owned by %q with %d positions, want %X %s %s owned by %q with %d positions",
+				i, e.EntityID, e.Kind, e.PacketType, e.Owner, len(e.Positions), w.id, w.kind, w.packetType, w.owner, w.positions)
+		}
+	}
+	for _, m := range r.GetMovementData() {
+		if want := map[string]int{"Target1": 121, "Target2": 31}[m.Username]; len(m.Positions) != want {
+			t.Errorf("got %d positions of %s, want %d without the entity packets", len(m.Positions), m.Username, want)
+		}
+	}
+}
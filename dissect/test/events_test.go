@@ -0,0 +1,98 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// timePacket encodes a >=Y8S1 round clock packet.
+func timePacket(seconds uint32) []byte {
+	b := []byte{0x1F, 0x07, 0xEF, 0xC9, 0x04}
+	return binary.LittleEndian.AppendUint32(b, seconds)
+}
+
+// countdown encodes the round clock packets of every second from from down to to.
+func countdown(from, to uint32) []byte {
+	b := make([]byte, 0)
+	for s := from; s >= to; s-- {
+		b = append(b, timePacket(s)...)
+	}
+	return b
+}
+
+func clockBody(seconds ...uint32) []byte {
+	body := make([]byte, 0)
+	for _, s := range seconds {
+		body = append(body, bytes.Repeat([]byte{0x10}, 64)...)
+		body = append(body, timePacket(s)...)
+	}
+	return append(body, bytes.Repeat([]byte{0x10}, 64)...)
+}
+
+func TestSubscribe_TimeTickEvent(t *testing.T) {
+	replay := buildReplay(t, replayProps, [][]byte{clockBody(180, 180, 179, 178, 178)}, true)
+	r, err := dissect.NewReader(bytes.NewReader(replay))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got := make([]string, 0)
+	dissect.Subscribe(r, func(e dissect.TimeTickEvent) error {
+		got = append(got, e.Time)
+		return nil
+	})
+	dissect.Subscribe(r, func(e dissect.KillEvent) error {
+		t.Errorf("unexpected kill event %v", e)
+		return nil
+	})
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	want := []string{"3:00", "2:59", "2:58"}
+	if len(got) != len(want) {
+		t.Fatalf("got ticks %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tick %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSubscribe_HandlerError(t *testing.T) {
+	replay := buildReplay(t, replayProps, [][]byte{clockBody(180, 179, 178)}, true)
+	r, err := dissect.NewReader(bytes.NewReader(replay))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	stop := errors.New("stop")
+	ticks := 0
+	dissect.Subscribe(r, func(e dissect.TimeTickEvent) error {
+		ticks++
+		return stop
+	})
+	if err = r.Read(); !errors.Is(err, stop) {
+		t.Errorf("Read(): expected handler error, got %v", err)
+	}
+	if ticks != 1 {
+		t.Errorf("handler called %d times, want 1", ticks)
+	}
+}
+
+func TestSubscribe_RoundEndHandlerError(t *testing.T) {
+	replay := buildReplay(t, replayProps, [][]byte{clockBody(180, 179)}, true)
+	r, err := dissect.NewReader(bytes.NewReader(replay))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	stop := errors.New("stop")
+	dissect.Subscribe(r, func(e dissect.RoundEndEvent) error {
+		return stop
+	})
+	if err = r.Read(); !errors.Is(err, stop) {
+		t.Errorf("Read(): expected handler error, got %v", err)
+	}
+}
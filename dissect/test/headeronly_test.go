@@ -0,0 +1,84 @@
+package test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestReadHeaderOnly(t *testing.T) {
+	for _, chunked := range []bool{true, false} {
+		replay := buildReplay(t, replayProps, [][]byte{clockBody(180)}, chunked)
+		if chunked {
+			// a corrupt second section is never decompressed
+			replay = append(replay, bytes.Repeat([]byte{0x01}, 16)...)
+			replay = append(replay, 0x28, 0xB5, 0x2F, 0xFD, 0xFF, 0xFF, 0xFF, 0xFF)
+		}
+		for _, players := range []bool{false, true} {
+			h, fields, err := dissect.ReadHeaderOnly(bytes.NewReader(replay), players)
+			if err != nil {
+				t.Fatalf("chunked=%v players=%v: expected no error, got %v", chunked, players, err)
+			}
+			if h.CodeVersion != dissect.Y9S1 || h.Map != dissect.Chalet {
+				t.Errorf("unexpected header %+v", h)
+			}
+			for _, f := range []dissect.HeaderField{dissect.HeaderCodeVersion, dissect.HeaderMap, dissect.HeaderTimestamp} {
+				if !slices.Contains(fields, f) {
+					t.Errorf("chunked=%v players=%v: %s not available", chunked, players, f)
+				}
+			}
+			for _, f := range []dissect.HeaderField{dissect.HeaderTeamWon, dissect.HeaderPlayers, dissect.HeaderPlayerOperators} {
+				if slices.Contains(fields, f) {
+					t.Errorf("chunked=%v players=%v: %s should not be available", chunked, players, f)
+				}
+			}
+		}
+	}
+}
+
+func TestReadHeaderOnly_Invalid(t *testing.T) {
+	if _, _, err := dissect.ReadHeaderOnly(bytes.NewReader([]byte("not a replay")), true); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestReadHeaderOnly_Players(t *testing.T) {
+	replay := objectiveReplayData(t, dissect.Bomb, dissect.Y9S1)
+	// a corrupt second section is never decompressed
+	replay = append(replay, bytes.Repeat([]byte{0x01}, 16)...)
+	replay = append(replay, 0x28, 0xB5, 0x2F, 0xFD, 0xFF, 0xFF, 0xFF, 0xFF)
+	h, fields, err := dissect.ReadHeaderOnly(bytes.NewReader(replay), false)
+	if err != nil {
+		t.Fatalf("players=false: expected no error, got %v", err)
+	}
+	if slices.Contains(fields, dissect.HeaderPlayerOperators) {
+		t.Errorf("players=false: operators should not be available")
+	}
+	h, fields, err = dissect.ReadHeaderOnly(bytes.NewReader(replay), true)
+	if err != nil {
+		t.Fatalf("players=true: expected no error, got %v", err)
+	}
+	for _, f := range []dissect.HeaderField{dissect.HeaderPlayers, dissect.HeaderPlayerOperators, dissect.HeaderPlayerSpawns, dissect.HeaderTeamRoles} {
+		if !slices.Contains(fields, f) {
+			t.Errorf("players=true: %s not available", f)
+		}
+	}
+	if len(h.Players) != 10 {
+		t.Fatalf("players=true: got %d players, want 10", len(h.Players))
+	}
+	for i, p := range h.Players {
+		// defenders take the spawn of the site, which this replay lacks
+		want, spawn := dissect.Ash, "Spawn"
+		if i >= 5 {
+			want, spawn = dissect.Rook, ""
+		}
+		if p.Operator != want || p.Spawn != spawn {
+			t.Errorf("players=true: got %s as %s at %q, want %s at %q", p.Username, p.Operator, p.Spawn, want, spawn)
+		}
+	}
+	if h.Teams[0].Role != dissect.Attack || h.Teams[1].Role != dissect.Defense {
+		t.Errorf("players=true: got roles %s and %s, want Attack and Defense", h.Teams[0].Role, h.Teams[1].Role)
+	}
+}
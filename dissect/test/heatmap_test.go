@@ -0,0 +1,149 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// lobbyReplay returns a read replay of Target1 leaving the ClubHouseY10 lobby
+// and being killed by Alpha at its end.
+func lobbyReplay(t *testing.T) *dissect.Reader {
+	t.Helper()
+	if err := dissect.LoadRooms(strings.NewReader(clubhouseRooms)); err != nil {
+		t.Fatalf("LoadRooms(): expected no error, got %v", err)
+	}
+	packets := append(lobbyExitPackets(), killPacket("Alpha", "Target1", killTypeKill, false))
+	r, err := dissect.NewReader(bytes.NewReader(mapReplayData(t, dissect.ClubHouseY10, dissect.Bomb, dissect.Y9S1, packets...)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r.EnableMovementTracking(0)
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	return r
+}
+
+func TestHeatmap(t *testing.T) {
+	r := lobbyReplay(t)
+	lobby := dissect.HeatmapKey{Map: "ClubHouseY10", Floor: "1F"}
+	tests := []struct {
+		name   string
+		layer  dissect.HeatmapLayer
+		filter dissect.HeatmapFilter
+		want   int
+	}{
+		{"positions", dissect.HeatPositions, dissect.HeatmapFilter{}, 50},
+		{"defense", dissect.HeatPositions, dissect.HeatmapFilter{Role: dissect.Defense}, 50},
+		{"attack", dissect.HeatPositions, dissect.HeatmapFilter{Role: dissect.Attack}, 0},
+		{"player", dissect.HeatPositions, dissect.HeatmapFilter{Players: []string{"Target2"}}, 0},
+		{"operator", dissect.HeatPositions, dissect.HeatmapFilter{Operators: []string{"Rook"}}, 50},
+		{"window", dissect.HeatPositions, dissect.HeatmapFilter{From: 1, To: 2}, 11},
+		{"deaths", dissect.HeatDeaths, dissect.HeatmapFilter{}, 1},
+		{"untracked kills", dissect.HeatKills, dissect.HeatmapFilter{}, 0},
+	}
+	for _, test := range tests {
+		h := dissect.NewHeatmap(test.layer, test.filter)
+		h.AddRound(r)
+		if got := h.Len(lobby); got != test.want {
+			t.Errorf("%s: got %d points, want %d", test.name, got, test.want)
+		}
+	}
+
+	h := dissect.NewHeatmap(dissect.HeatDeaths, dissect.HeatmapFilter{})
+	h.AddRound(r)
+	if keys := h.Keys(); len(keys) != 1 || keys[0] != lobby {
+		t.Fatalf("Keys(): got %v, want [%v]", keys, lobby)
+	}
+	var out bytes.Buffer
+	if err := h.WritePNG(&out, lobby, 200); err != nil {
+		t.Fatalf("WritePNG(): expected no error, got %v", err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the image spans the lobby (-1, -5 to 10.25, 10) and the death
+	// at (15, 1) with a 3 meter margin, 22 by 21 meters
+	bounds := img.Bounds()
+	if bounds.Dx() != 200 || bounds.Dy() != 191 {
+		t.Errorf("got a %dx%d image, want 200x191", bounds.Dx(), bounds.Dy())
+	}
+	death := img.At(200*19/22, 200*12/22)
+	if r, _, b, _ := death.RGBA(); r>>8 != 0xFF || b>>8 != 0 {
+		t.Errorf("got color %v at the death, want red", death)
+	}
+
+	// a hall 94 meters long spans 10 by 100 meters with the margin
+	dissect.RegisterMapGeometry(dissect.MapGeometry{
+		Map:    "HallY10",
+		Floors: []dissect.Floor{{Name: "1F", MinZ: -1, MaxZ: 3}},
+		Rooms:  []dissect.Room{{Name: "1F Hall", Floor: "1F", Polygon: [][2]float32{{0, 0}, {4, 0}, {4, 94}, {0, 94}}}},
+	})
+	bounds = h.Image(dissect.HeatmapKey{Map: "HallY10", Floor: "1F"}, 200).Bounds()
+	if bounds.Dx() != 20 || bounds.Dy() != 200 {
+		t.Errorf("got a %dx%d image of the hall, want 20x200", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestHeatmap_Finisher(t *testing.T) {
+	if err := dissect.LoadRooms(strings.NewReader(clubhouseRooms)); err != nil {
+		t.Fatalf("LoadRooms(): expected no error, got %v", err)
+	}
+	// Target2 downs Alpha, who is finished off by the tracked Target1
+	packets := append(lobbyExitPackets(),
+		killPacket("Target2", "Alpha", killTypeDBNO, false),
+		killPacket("Target1", "Alpha", killTypeKill, false),
+	)
+	r, err := dissect.NewReader(bytes.NewReader(mapReplayData(t, dissect.ClubHouseY10, dissect.Bomb, dissect.Y9S1, packets...)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r.EnableMovementTracking(0)
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	h := dissect.NewHeatmap(dissect.HeatKills, dissect.HeatmapFilter{Players: []string{"Target1"}})
+	h.AddRound(r)
+	if got := h.Len(dissect.HeatmapKey{Map: "ClubHouseY10", Floor: "1F"}); got != 1 {
+		t.Errorf("got %d kill points for the finisher, want 1", got)
+	}
+}
+
+func TestHeatmap_AddMatch(t *testing.T) {
+	if err := dissect.LoadRooms(strings.NewReader(clubhouseRooms)); err != nil {
+		t.Fatalf("LoadRooms(): expected no error, got %v", err)
+	}
+	dir := t.TempDir()
+	replay := mapReplayData(t, dissect.ClubHouseY10, dissect.Bomb, dissect.Y9S1, lobbyExitPackets()...)
+	for i := 1; i <= 2; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("Match-R%02d.rec", i)), replay, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	m, err := dissect.NewMatchReader(in)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	m.TrackMovement = true
+	if err = m.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	h := dissect.NewHeatmap(dissect.HeatPositions, dissect.HeatmapFilter{})
+	h.AddMatch(m)
+	if got := h.Len(dissect.HeatmapKey{Map: "ClubHouseY10", Floor: "1F"}); got != 100 {
+		t.Errorf("got %d points over the match, want 100", got)
+	}
+}
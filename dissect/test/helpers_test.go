@@ -2,6 +2,13 @@
 
 package test
 
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
 // sliceDiff returns a list of items that are in a, but not in b
 // with O(n) complexity
 func sliceDiff[T comparable](a, b []T) (diff []T) {
@@ -22,3 +29,50 @@ func sliceDiff[T comparable](a, b []T) (diff []T) {
 	}
 	return
 }
+
+// replayProps are the header properties required by dissect.NewReader.
+// teamscore1 must be last as it terminates the header.
+var replayProps = [][2]string{
+	{"version", "Y9S1"},
+	{"code", "8111697"},
+	{"datetime", "2024-05-04-02-14-08"},
+	{"matchtype", "4"},
+	{"worldid", "259816839773"},
+	{"recordingplayerid", "1"},
+	{"gamemodeid", "327933806"},
+	{"roundspermatch", "12"},
+	{"roundspermatchovertime", "3"},
+	{"roundnumber", "0"},
+	{"overtimeroundnumber", "0"},
+	{"teamscore0", "0"},
+	{"teamscore1", "0"},
+}
+
+// buildReplay assembles a synthetic replay file from header props and body sections.
+// Chunked replays store the header uncompressed followed by one zstd frame per section,
+// otherwise the header and body are compressed as a single frame.
+func buildReplay(t *testing.T, props [][2]string, sections [][]byte, chunked bool) []byte {
+	t.Helper()
+	header := []byte("dissect")
+	header = append(header, 0x01, 0, 0, 0, 0, 0, 0, 0, 0x02, 0, 0, 0, 0, 0, 0, 0)
+	for _, prop := range props {
+		for _, s := range prop {
+			header = append(header, byte(len(s)), 0, 0, 0, 0, 0, 0, 0)
+			header = append(header, s...)
+		}
+	}
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+	if !chunked {
+		return enc.EncodeAll(append(header, bytes.Join(sections, nil)...), nil)
+	}
+	out := header
+	for _, section := range sections {
+		out = append(out, bytes.Repeat([]byte{0x01}, 16)...)
+		out = enc.EncodeAll(section, out)
+	}
+	return out
+}
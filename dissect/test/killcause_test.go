@@ -0,0 +1,162 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// Kill type bytes of the match feedback kill packet.
+const (
+	killTypeDeath = 0x00
+	killTypeDBNO  = 0x01
+	killTypeKill  = 0x02
+)
+
+// killPacket returns a Y9S1 match feedback kill packet. An empty
+// attacker with killTypeDeath is a death without an attacker.
+func killPacket(attacker, target string, killType byte, headshot bool) []byte {
+	return killPacketFor(dissect.Y9S1, attacker, target, killType, headshot)
+}
+
+// killPacketFor returns a match feedback kill packet for replays of the code version.
+func killPacketFor(code int, attacker, target string, killType byte, headshot bool) []byte {
+	b := []byte{0x59, 0x34, 0xE5, 0x8B, 0x04}
+	if code >= dissect.Y9S1Update3 {
+		b = append(b, make([]byte, 38)...)
+	} else {
+		b = append(b, make([]byte, 9)...)
+		b = append(b, 0x04) // valid
+		b = append(b, make([]byte, 24)...)
+	}
+	b = append(b, 0x00) // size
+	b = append(b, 0x22, 0xd9, 0x13, 0x3c, 0xba)
+	b = append(b, byte(len(attacker)))
+	b = append(b, attacker...)
+	typeBytes := make([]byte, 15)
+	typeBytes[6] = killType
+	b = append(b, typeBytes...)
+	b = append(b, byte(len(target)))
+	b = append(b, target...)
+	b = append(b, make([]byte, 56)...)
+	if headshot {
+		return append(b, 0x01)
+	}
+	return append(b, 0x00)
+}
+
+// ammoPacket returns an ammo packet of the entity. Full packets include
+// the magazine capacity, reserve and total ammo used to classify the entity.
+func ammoPacket(entity uint32, magazine, total uint32, full bool) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, entity)
+	b = append(b, 0x00, 0x00, 0x00, 0x00)
+	b = append(b, 0x77, 0xCA, 0x96, 0xDE, 0x04)
+	b = binary.LittleEndian.AppendUint32(b, magazine)
+	if full {
+		field := func(id []byte, v uint32) {
+			b = append(b, 0x22)
+			b = append(b, id...)
+			b = append(b, 0x04)
+			b = binary.LittleEndian.AppendUint32(b, v)
+		}
+		field([]byte{0x6D, 0x5B, 0x6D, 0x3E}, total-magazine)
+		field([]byte{0x56, 0xF5, 0x44, 0x0A}, magazine-1)
+		field([]byte{0x40, 0x0A, 0xC8, 0x29}, total)
+	}
+	return append(b, 0x10)
+}
+
+// teamProps returns header props with the players of both teams.
+func teamProps(team0, team1 []string) [][2]string {
+	props := slices.Clone(replayProps[:len(replayProps)-1])
+	for i, team := range [][]string{team0, team1} {
+		for _, name := range team {
+			props = append(props,
+				[2]string{"playerid", "1234"},
+				[2]string{"playername", name},
+				[2]string{"team", strconv.Itoa(i)},
+				[2]string{"heroname", "0"},
+				[2]string{"alliance", "0"},
+				[2]string{"roleimage", "0"},
+				[2]string{"rolename", ""},
+				[2]string{"roleportrait", "0"},
+			)
+		}
+	}
+	props = append(props, [2]string{"playlistcategory", "0"})
+	return append(props, replayProps[len(replayProps)-1])
+}
+
+// join concatenates packets separated by padding.
+func join(packets ...[]byte) []byte {
+	body := make([]byte, 0)
+	for _, p := range packets {
+		body = append(body, bytes.Repeat([]byte{0x10}, 64)...)
+		body = append(body, p...)
+	}
+	return append(body, bytes.Repeat([]byte{0x10}, 64)...)
+}
+
+func TestReader_KillCause(t *testing.T) {
+	// ammo entities are mapped to header players in order: Alpha, then Bravo
+	const primary0, primary1, secondary1 = 0x1001, 0x2001, 0x2002
+	body := join(
+		timePacket(180),
+		ammoPacket(primary0, 31, 181, true),
+		make([]byte, 500),
+		ammoPacket(primary1, 31, 181, true),
+		ammoPacket(secondary1, 13, 65, true),
+		timePacket(170),
+		ammoPacket(primary0, 30, 180, false),
+		killPacket("Alpha", "Target1", killTypeKill, true),
+		timePacket(160),
+		ammoPacket(secondary1, 12, 64, false),
+		killPacket("Bravo", "Target2", killTypeDBNO, false),
+		timePacket(150),
+		killPacket("Finisher", "Target2", killTypeKill, false),
+		timePacket(140),
+		killPacket("Alpha", "Target3", killTypeKill, false),
+		killPacket("", "Target4", killTypeDeath, false),
+		killPacket("Alpha", "Target5", killTypeDBNO, false),
+		killPacket("", "Target5", killTypeDeath, false),
+		killPacket("Bravo", "Bravo", killTypeKill, false),
+	)
+	props := teamProps([]string{"Alpha", "Bravo", "Target4"}, []string{"Target1", "Target2", "Target3", "Target5"})
+	r, err := dissect.NewReader(bytes.NewReader(buildReplay(t, props, [][]byte{body}, true)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	want := []struct {
+		typ      dissect.MatchUpdateType
+		username string
+		target   string
+		cause    dissect.KillCause
+	}{
+		{dissect.Kill, "Alpha", "Target1", dissect.KillCausePrimary},
+		{dissect.DBNO, "Bravo", "Target2", dissect.KillCauseSecondary},
+		{dissect.Kill, "Bravo", "Target2", dissect.KillCauseSecondary},
+		{dissect.Kill, "Alpha", "Target3", dissect.KillCauseUnknown},
+		{dissect.Death, "Target4", "", dissect.KillCauseUnknown},
+		{dissect.DBNO, "Alpha", "Target5", dissect.KillCauseUnknown},
+		{dissect.BleedOut, "Target5", "", dissect.KillCauseBleedOut},
+		{dissect.Kill, "Bravo", "Bravo", dissect.KillCauseSuicide},
+		{dissect.RoundEnd, "", "", ""},
+	}
+	if len(r.MatchFeedback) != len(want) {
+		t.Fatalf("got %d match updates, want %d: %+v", len(r.MatchFeedback), len(want), r.MatchFeedback)
+	}
+	for i, w := range want {
+		u := r.MatchFeedback[i]
+		if u.Type != w.typ || u.Username != w.username || u.Target != w.target || u.Cause != w.cause {
+			t.Errorf("update %d: got %v %s -> %s (%s), want %v %s -> %s (%s)",
+				i, u.Type, u.Username, u.Target, u.Cause, w.typ, w.username, w.target, w.cause)
+		}
+	}
+}
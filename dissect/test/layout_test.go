@@ -0,0 +1,83 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestLayoutFor(t *testing.T) {
+	tests := []struct {
+		packet      dissect.Packet
+		codeVersion int
+		indicator   string
+		want        []byte
+	}{
+		{dissect.PacketPlayer, dissect.Y7S1, "id", []byte{0xE6, 0xF9, 0x7D, 0x86}},
+		{dissect.PacketPlayer, dissect.Y7S2, "id", []byte{0xE6, 0xF9, 0x7D, 0x86}},
+		{dissect.PacketPlayer, dissect.Y7S4, "id", []byte{0x33, 0xD8, 0x3D, 0x4F, 0x23}},
+		{dissect.PacketPlayer, dissect.Y7S4, "operator", []byte{0x40, 0xF2, 0x15, 0x04}},
+		{dissect.PacketPlayer, dissect.Y9S3, "uiID", []byte{0x38, 0xDF, 0xEE, 0x88}},
+		{dissect.PacketPlayer, dissect.Y9S2, "uiID", nil},
+	}
+	for _, test := range tests {
+		l, ok := dissect.LayoutFor(test.packet, test.codeVersion)
+		if !ok {
+			t.Fatalf("%s: no layout for %d", test.packet, test.codeVersion)
+		}
+		if got := l.Indicators[test.indicator]; !bytes.Equal(got, test.want) {
+			t.Errorf("%s@%d %s = %X, want %X", test.packet, test.codeVersion, test.indicator, got, test.want)
+		}
+	}
+	if l, _ := dissect.LayoutFor(dissect.PacketMatchFeedback, dissect.Y9S1Update3); l.Offsets["header"] != 38 {
+		t.Errorf("match feedback header offset = %d, want 38", l.Offsets["header"])
+	}
+	if l, _ := dissect.LayoutFor(dissect.PacketDefuserTimer, dissect.Y10S4); l.Offsets["playerID"] != 0 {
+		t.Errorf("Y10S4 defuser timer should not include a player id")
+	}
+	if l, _ := dissect.LayoutFor(dissect.PacketTime, dissect.Y7S4); l.Marker != nil {
+		t.Errorf("Y8S1 time packet should be disabled for Y7S4, got marker %X", l.Marker)
+	}
+}
+
+func TestLoadLayouts(t *testing.T) {
+	const future = 99000000
+	layouts := `[{"packet": "time", "since": 99000000, "layout": {"marker": "5A 6B 7C 8D"}}]`
+	if err := dissect.LoadLayouts(strings.NewReader(layouts)); err != nil {
+		t.Fatalf("LoadLayouts(): expected no error, got %v", err)
+	}
+	if l, _ := dissect.LayoutFor(dissect.PacketTime, future-1); !bytes.Equal(l.Marker, []byte{0x1F, 0x07, 0xEF, 0xC9}) {
+		t.Errorf("layouts before %d changed: %X", future, l.Marker)
+	}
+	props := [][2]string{{"startingteamscore0", "0"}, {"startingteamscore1", "0"}}
+	props = append(props, replayProps...)
+	props[3] = [2]string{"code", "99000000"}
+	body := make([]byte, 0)
+	for _, seconds := range []uint32{180, 179} {
+		body = append(body, bytes.Repeat([]byte{0x10}, 64)...)
+		body = append(body, 0x5A, 0x6B, 0x7C, 0x8D, 0x04)
+		body = binary.LittleEndian.AppendUint32(body, seconds)
+		body = append(body, timePacket(seconds-100)...) // old marker is ignored
+	}
+	r, err := dissect.NewReader(bytes.NewReader(buildReplay(t, props, [][]byte{body}, true)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got := make([]string, 0)
+	dissect.Subscribe(r, func(e dissect.TimeTickEvent) error {
+		got = append(got, e.Time)
+		return nil
+	})
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	if strings.Join(got, ",") != "3:00,2:59" {
+		t.Errorf("got ticks %v, want [3:00 2:59]", got)
+	}
+	if err = dissect.LoadLayouts(strings.NewReader(`[{"since": 1}]`)); err == nil {
+		t.Errorf("LoadLayouts(): expected error for missing packet")
+	}
+}
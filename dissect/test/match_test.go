@@ -0,0 +1,141 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// writeMatch writes a match folder where round i contains i+1 test markers holding i.
+// Rounds listed in invalid are written as garbage.
+func writeMatch(t *testing.T, rounds int, invalid ...int) *os.File {
+	t.Helper()
+	dir := t.TempDir()
+	for i := 0; i < rounds; i++ {
+		body := make([]byte, 0)
+		for j := 0; j <= i; j++ {
+			body = append(body, bytes.Repeat([]byte{0x10}, 32)...)
+			body = append(body, testMarker...)
+			body = binary.LittleEndian.AppendUint32(body, uint32(i))
+		}
+		body = append(body, bytes.Repeat([]byte{0x10}, 32)...)
+		replay := buildReplay(t, replayProps, [][]byte{body}, true)
+		for _, n := range invalid {
+			if n == i {
+				replay = []byte("not a replay")
+			}
+		}
+		name := filepath.Join(dir, fmt.Sprintf("Match-R%02d.rec", i+1))
+		if err := os.WriteFile(name, replay, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestMatchReader_SequentialByDefault(t *testing.T) {
+	m, err := dissect.NewMatchReader(writeMatch(t, 8))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var running, overlaps atomic.Int32
+	m.Listen(testMarker, func(r *dissect.Reader) error {
+		if running.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return nil
+	})
+	if err = m.Read(); err != nil {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	if n := overlaps.Load(); n > 0 {
+		t.Errorf("got %d concurrent callbacks with Workers unset, want none", n)
+	}
+}
+
+func TestMatchReader_Workers(t *testing.T) {
+	const rounds = 8
+	for _, workers := range []int{1, 3, 0} {
+		m, err := dissect.NewMatchReader(writeMatch(t, rounds))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		m.Workers = workers
+		var mu sync.Mutex
+		seen := make(map[*dissect.Reader][]uint32)
+		m.Listen(testMarker, func(r *dissect.Reader) error {
+			b, err := r.Bytes(4)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			seen[r] = append(seen[r], binary.LittleEndian.Uint32(b))
+			mu.Unlock()
+			return nil
+		})
+		if err = m.Read(); err != nil {
+			t.Fatalf("workers=%d: Read(): expected no error, got %v", workers, err)
+		}
+		if len(seen) != rounds {
+			t.Fatalf("workers=%d: listeners ran for %d rounds, want %d", workers, len(seen), rounds)
+		}
+		for i := 0; i < rounds; i++ {
+			r, err := m.RoundAt(i)
+			if err != nil {
+				t.Fatalf("RoundAt(%d): expected no error, got %v", i, err)
+			}
+			got := seen[r]
+			if len(got) != i+1 {
+				t.Errorf("workers=%d: round %d has %d markers, want %d", workers, i, len(got), i+1)
+			}
+			for _, v := range got {
+				if v != uint32(i) {
+					t.Errorf("workers=%d: round %d read marker of round %d", workers, i, v)
+				}
+			}
+		}
+	}
+}
+
+func TestMatchReader_RoundErrors(t *testing.T) {
+	m, err := dissect.NewMatchReader(writeMatch(t, 6, 1, 4))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	m.Workers = 4
+	err = m.Read()
+	if err == nil {
+		t.Fatal("Read(): expected error")
+	}
+	for _, name := range []string{"Match-R02.rec", "Match-R05.rec"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not name %s", err, name)
+		}
+	}
+	var roundErr *dissect.RoundError
+	if !errors.As(err, &roundErr) || roundErr.Round != 1 || !errors.Is(err, dissect.ErrInvalidFile) {
+		t.Errorf("unexpected error %v", err)
+	}
+	for _, i := range []int{0, 2, 3, 5} {
+		if _, err := m.RoundAt(i); err != nil {
+			t.Errorf("RoundAt(%d): expected no error, got %v", i, err)
+		}
+	}
+}
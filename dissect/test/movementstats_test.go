@@ -0,0 +1,149 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+	"github.com/xuri/excelize/v2"
+)
+
+// walkPackets returns the packets of Target1 walking 5 meters per second
+// for 4 seconds, then standing still for 1 second.
+func walkPackets() [][]byte {
+	packets := [][]byte{timePacket(180)}
+	for i := range 50 {
+		x := float32(min(i, 39)) * 0.5
+		packets = append(packets, positionPacket(0x1010, 0xB8, 0x01, x, 1, 1, 10))
+		if (i+1)%10 == 0 {
+			packets = append(packets, timePacket(uint32(180-(i+1)/10)))
+		}
+	}
+	return packets
+}
+
+func TestReader_MovementStats(t *testing.T) {
+	r := objectiveReplay(t, dissect.Bomb, dissect.Y9S1, walkPackets()...)
+	r.EnableMovementTracking(0)
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	var got *dissect.MovementStats
+	for _, s := range r.PlayerStats() {
+		if s.Username == "Target1" {
+			got = s.Movement
+		} else if s.Movement != nil {
+			t.Errorf("got movement stats for untracked %s", s.Username)
+		}
+	}
+	if got == nil {
+		t.Fatal("got no movement stats for Target1")
+	}
+	near := func(name string, got, want float64) {
+		if math.Abs(got-want) > 1e-6 {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+	near("distance", got.Distance, 19.5)
+	near("tracked time", got.TrackedTime, 4.9)
+	near("average speed", got.AverageSpeed, 19.5/4.9)
+	near("peak speed", got.PeakSpeed, 5)
+	near("stationary time", got.StationaryTime, 1)
+	near("1F time", got.FloorTime["1F"], 4.9)
+	if got.OutsideTime != nil {
+		t.Errorf("got outside time %v on a map without complete room geometry", *got.OutsideTime)
+	}
+}
+
+func TestReader_MovementStatsOutside(t *testing.T) {
+	if err := dissect.LoadRooms(strings.NewReader(clubhouseRooms)); err != nil {
+		t.Fatalf("LoadRooms(): expected no error, got %v", err)
+	}
+	r, err := dissect.NewReader(bytes.NewReader(mapReplayData(t, dissect.ClubHouseY10, dissect.Bomb, dissect.Y9S1, lobbyExitPackets()...)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r.EnableMovementTracking(0)
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	got, ok := r.MovementStats()["Target1"]
+	if !ok || got.OutsideTime == nil {
+		t.Fatalf("got no outside time for Target1: %+v", got)
+	}
+	if math.Abs(*got.OutsideTime-2.1) > 1e-6 {
+		t.Errorf("got outside time %v, want 2.1", *got.OutsideTime)
+	}
+}
+
+func TestReader_MovementStatsPartialGeometry(t *testing.T) {
+	partial := strings.Replace(clubhouseRooms, `"complete": true,`, "", 1)
+	if err := dissect.LoadRooms(strings.NewReader(partial)); err != nil {
+		t.Fatalf("LoadRooms(): expected no error, got %v", err)
+	}
+	defer dissect.LoadRooms(strings.NewReader(clubhouseRooms))
+	r, err := dissect.NewReader(bytes.NewReader(mapReplayData(t, dissect.ClubHouseY10, dissect.Bomb, dissect.Y9S1, lobbyExitPackets()...)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r.EnableMovementTracking(0)
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	if got := r.MovementStats()["Target1"]; got.OutsideTime != nil {
+		t.Errorf("got outside time %v, want none without complete geometry", *got.OutsideTime)
+	}
+}
+
+func TestMatchReader_MovementStats(t *testing.T) {
+	dir := t.TempDir()
+	replay := objectiveReplayData(t, dissect.Bomb, dissect.Y9S1, walkPackets()...)
+	for i := 1; i <= 2; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("Match-R%02d.rec", i)), replay, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	m, err := dissect.NewMatchReader(in)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	m.TrackMovement = true
+	if err = m.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	for _, s := range m.PlayerStats() {
+		if s.Username == "Target1" && (s.Movement == nil || math.Abs(s.Movement.Distance-39) > 1e-6) {
+			t.Errorf("got match movement %+v for Target1, want a distance of 39", s.Movement)
+		}
+	}
+	var out bytes.Buffer
+	if err = m.WriteExcel(&out); err != nil {
+		t.Fatalf("WriteExcel(): expected no error, got %v", err)
+	}
+	f, err := excelize.OpenReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	player, err := f.GetCellValue("Movement", "A3")
+	if err != nil || player != "Target1" {
+		t.Errorf("Movement sheet: got %q (%v) in A3, want Target1", player, err)
+	}
+	rows, err := f.GetRows("Movement")
+	if err != nil || len(rows) < 2 {
+		t.Fatalf("Movement sheet: got rows %v (%v)", rows, err)
+	}
+	if floors := rows[1][6:]; len(floors) != 1 || floors[0] != "1F (s)" {
+		t.Errorf("Movement sheet: got floor columns %q, want only the 1F walked on", floors)
+	}
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// messagePacket returns a pre-Y9S1 match feedback message packet.
+func messagePacket(msg string) []byte {
+	b := []byte{0x59, 0x34, 0xE5, 0x8B, 0x04, 0x00}
+	b = append(b, 0x00, 0x00, 0x00, 0x22, 0xe3, 0x09, 0x00, 0x79)
+	b = append(b, byte(len(msg)))
+	return append(b, msg...)
+}
+
+// objectiveReplay returns a reader of a 5v5 replay of the game mode and code version with
+// the packets after the player packets. Alpha and Bravo attack, Target1 and Target2 defend.
+func objectiveReplay(t *testing.T, mode dissect.GameMode, code int, body ...[]byte) *dissect.Reader {
+	t.Helper()
+	r, err := dissect.NewReader(bytes.NewReader(objectiveReplayData(t, mode, code, body...)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return r
+}
+
+// objectiveReplayData returns the replay read by objectiveReplay.
+func objectiveReplayData(t *testing.T, mode dissect.GameMode, code int, body ...[]byte) []byte {
+	t.Helper()
+	return mapReplayData(t, dissect.Chalet, mode, code, body...)
+}
+
+// mapReplayData returns the replay read by objectiveReplay played on the map.
+func mapReplayData(t *testing.T, m dissect.Map, mode dissect.GameMode, code int, body ...[]byte) []byte {
+	t.Helper()
+	attackers := []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}
+	defenders := []string{"Target1", "Target2", "Target3", "Target4", "Target5"}
+	packets := make([][]byte, 0)
+	for i, name := range append(attackers, defenders...) {
+		op := dissect.Ash
+		if i >= len(attackers) {
+			op = dissect.Rook
+		}
+		packets = append(packets, playerPacket(name, op, byte(i+1)))
+	}
+	props := teamProps(attackers, defenders)
+	for i := range props {
+		switch props[i][0] {
+		case "code":
+			props[i][1] = strconv.Itoa(code)
+		case "gamemodeid":
+			props[i][1] = strconv.Itoa(int(mode))
+		case "worldid":
+			props[i][1] = strconv.Itoa(int(m))
+		}
+	}
+	return buildReplay(t, props, [][]byte{join(append(packets, body...)...)}, true)
+}
+
+func TestReader_Hostage(t *testing.T) {
+	r := objectiveReplay(t, dissect.Hostage, dissect.Y9S1,
+		timePacket(120),
+		killPacket("Target1", "Hostage", killTypeDBNO, false),
+		timePacket(110),
+		killPacket("Target1", "Hostage", killTypeKill, false),
+	)
+	objectives := 0
+	dissect.Subscribe(r, func(e dissect.ObjectiveEvent) error {
+		objectives++
+		return nil
+	})
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	if objectives != 2 {
+		t.Errorf("got %d objective events, want 2", objectives)
+	}
+	want := []dissect.MatchUpdateType{dissect.HostageDBNO, dissect.HostageKill, dissect.RoundEnd}
+	if len(r.MatchFeedback) != len(want) {
+		t.Fatalf("got %d match updates, want %d: %+v", len(r.MatchFeedback), len(want), r.MatchFeedback)
+	}
+	for i, typ := range want {
+		if u := r.MatchFeedback[i]; u.Type != typ {
+			t.Errorf("update %d: got %v, want %v", i, u.Type, typ)
+		}
+	}
+	if team := r.Header.Teams[0]; !team.Won || team.WinCondition != dissect.KilledHostage {
+		t.Errorf("attackers: got won=%v by %s, want won by KilledHostage", team.Won, team.WinCondition)
+	}
+	for _, s := range r.PlayerStats() {
+		if s.Kills != 0 {
+			t.Errorf("hostage kill counted for %s", s.Username)
+		}
+	}
+}
+
+func TestReader_SecureArea(t *testing.T) {
+	r := objectiveReplay(t, dissect.SecureArea, dissect.Y8S4,
+		timePacket(120),
+		messagePacket("Target1 contested the area"),
+		timePacket(110),
+		messagePacket("Alpha secured the area"),
+	)
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	want := []struct {
+		typ      dissect.MatchUpdateType
+		username string
+	}{
+		{dissect.AreaContested, "Target1"},
+		{dissect.AreaSecured, "Alpha"},
+		{dissect.RoundEnd, ""},
+	}
+	if len(r.MatchFeedback) != len(want) {
+		t.Fatalf("got %d match updates, want %d: %+v", len(r.MatchFeedback), len(want), r.MatchFeedback)
+	}
+	for i, w := range want {
+		u := r.MatchFeedback[i]
+		if u.Type != w.typ || u.Username != w.username {
+			t.Errorf("update %d: got %v %q, want %v %q", i, u.Type, u.Username, w.typ, w.username)
+		}
+	}
+	if team := r.Header.Teams[0]; !team.Won || team.WinCondition != dissect.SecuredArea {
+		t.Errorf("attackers: got won=%v by %s, want won by SecuredArea", team.Won, team.WinCondition)
+	}
+	for _, s := range r.PlayerStats() {
+		if s.Username == "Alpha" && s.AreaSecures != 1 {
+			t.Errorf("got %d area secures for Alpha, want 1", s.AreaSecures)
+		}
+	}
+}
+
+func TestReader_ObjectiveMessageFormats(t *testing.T) {
+	r := objectiveReplay(t, dissect.SecureArea, dissect.Y8S4,
+		timePacket(120),
+		messagePacket("Target1 left the game"),
+		messagePacket("Alpha is securing the area"),
+		messagePacket("Nobody secured the area"),
+		messagePacket("Bravo contested the area"),
+	)
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	want := []struct {
+		typ      dissect.MatchUpdateType
+		username string
+	}{
+		{dissect.PlayerLeave, "Target1"},
+		{dissect.Other, ""},
+		{dissect.Other, ""},
+		{dissect.AreaContested, "Bravo"},
+	}
+	if len(r.MatchFeedback) < len(want) {
+		t.Fatalf("got %d match updates, want at least %d: %+v", len(r.MatchFeedback), len(want), r.MatchFeedback)
+	}
+	for i, w := range want {
+		u := r.MatchFeedback[i]
+		if u.Type != w.typ || u.Username != w.username {
+			t.Errorf("update %d: got %v %q, want %v %q", i, u.Type, u.Username, w.typ, w.username)
+		}
+	}
+}
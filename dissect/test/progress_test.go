@@ -0,0 +1,59 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestReader_ReadContext(t *testing.T) {
+	sections, markers := markerBody(4, 200*1024)
+	replay := buildReplay(t, replayProps, sections, true)
+	for _, open := range []func([]byte) (*dissect.Reader, error){
+		func(b []byte) (*dissect.Reader, error) { return dissect.NewReader(bytes.NewReader(b)) },
+		func(b []byte) (*dissect.Reader, error) { return dissect.NewStreamReader(bytes.NewReader(b)) },
+	} {
+		r, err := open(replay)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		r.Listen(testMarker, func(r *dissect.Reader) error { return nil })
+		var last dissect.Progress
+		r.OnProgress = func(p dissect.Progress) {
+			if p.MarkersDispatched < last.MarkersDispatched {
+				t.Errorf("progress went backwards: %d after %d", p.MarkersDispatched, last.MarkersDispatched)
+			}
+			last = p
+		}
+		if err = r.ReadContext(context.Background()); !dissect.Ok(err) {
+			t.Fatalf("ReadContext(): expected no error, got %v", err)
+		}
+		if last.MarkersDispatched != markers {
+			t.Errorf("last progress reported %d markers, want %d", last.MarkersDispatched, markers)
+		}
+		if last.BytesDecompressed == 0 {
+			t.Error("last progress reported no decompressed bytes")
+		}
+
+		r, err = open(replay)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		dispatched := 0
+		r.Listen(testMarker, func(r *dissect.Reader) error {
+			dispatched++
+			cancel()
+			return nil
+		})
+		if err = r.ReadContext(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("ReadContext(): expected context.Canceled, got %v", err)
+		}
+		if dispatched != 1 {
+			t.Errorf("got %d markers dispatched after cancelling, want 1", dispatched)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestReader_Rescan(t *testing.T) {
+	body := clockBody(180, 179, 178)
+	body = append(body, testMarker...)
+	body = binary.LittleEndian.AppendUint32(body, 7)
+	body = append(body, bytes.Repeat([]byte{0x10}, 32)...)
+	replay := buildReplay(t, replayProps, [][]byte{body}, true)
+	for name, open := range map[string]func([]byte) (*dissect.Reader, error){
+		"memory": func(b []byte) (*dissect.Reader, error) { return dissect.NewReader(bytes.NewReader(b)) },
+		"stream": func(b []byte) (*dissect.Reader, error) { return dissect.NewStreamReader(bytes.NewReader(b)) },
+	} {
+		r, err := open(replay)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		r.Retain = true
+		r.Lenient = true
+		r.EnableMovementTracking(3)
+		if err = r.Read(); !dissect.Ok(err) {
+			t.Fatalf("%s: Read(): expected no error, got %v", name, err)
+		}
+		markers := 0
+		ticks := make([]string, 0)
+		err = r.Rescan(func(r *dissect.Reader) {
+			r.Listen(testMarker, func(r *dissect.Reader) error {
+				markers++
+				return nil
+			})
+		})
+		if err != nil || markers != 1 {
+			t.Errorf("%s: Rescan(): got %d markers, err %v", name, markers, err)
+		}
+		err = r.Rescan(func(r *dissect.Reader) {
+			r.ListenDefaults()
+			dissect.Subscribe(r, func(e dissect.TimeTickEvent) error {
+				ticks = append(ticks, e.Time)
+				return nil
+			})
+		})
+		if err != nil {
+			t.Errorf("%s: Rescan(): expected no error, got %v", name, err)
+		}
+		if markers != 1 {
+			t.Errorf("%s: listener of an earlier pass ran again", name)
+		}
+		if strings.Join(ticks, ",") != "3:00,2:59,2:58" {
+			t.Errorf("%s: got ticks %v, want [3:00 2:59 2:58]", name, ticks)
+		}
+		if !r.Retain || !r.Lenient || !r.TrackMovement || r.MovementSampleRate != 3 {
+			t.Errorf("%s: ListenDefaults() lost the options %+v", name, r.ReaderOptions)
+		}
+	}
+}
+
+func TestReader_RescanNotRetained(t *testing.T) {
+	replay := buildReplay(t, replayProps, [][]byte{clockBody(180)}, true)
+	r, err := dissect.NewReader(bytes.NewReader(replay))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	if err = r.Rescan(func(*dissect.Reader) {}); !errors.Is(err, dissect.ErrNotRetained) {
+		t.Errorf("Rescan(): expected ErrNotRetained, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// clubhouseRooms has an L-shaped lobby on a first floor lower than the default.
+const clubhouseRooms = `{
+	"version": 1,
+	"maps": [{
+		"map": "ClubHouseY10",
+		"complete": true,
+		"floors": [
+			{"name": "1F", "minZ": -1, "maxZ": 3},
+			{"name": "2F", "minZ": 3, "maxZ": 7},
+			{"name": "Roof", "minZ": 7, "maxZ": 12, "outdoor": true}
+		],
+		"rooms": [
+			{"name": "1F Lobby", "floor": "1F", "polygon": [[-1, -5], [10.25, -5], [10.25, 5], [4, 5], [4, 10], [-1, 10]]}
+		]
+	}]
+}`
+
+func TestLoadRooms(t *testing.T) {
+	if err := dissect.LoadRooms(strings.NewReader(clubhouseRooms)); err != nil {
+		t.Fatalf("LoadRooms(): expected no error, got %v", err)
+	}
+	tests := []struct {
+		mapName string
+		x, y, z float32
+		want    string
+	}{
+		{"ClubHouseY10", 2, 8, 1, "1F Lobby"},
+		{"ClubHouseY10", 8, 8, 1, "1F"},
+		{"ClubHouseY10", 2, 8, 4, "2F"},
+		{"ClubHouseY10", 2, 8, 30, "Roof"},
+		{"ChaletY10", -5, -20, 6, "2F Master Bedroom"},
+		{"Oregon", -5, -20, 6, "2F"},
+	}
+	for _, test := range tests {
+		if got := dissect.GetRoomAtPosition(test.mapName, test.x, test.y, test.z); got != test.want {
+			t.Errorf("GetRoomAtPosition(%s, %v, %v, %v) = %q, want %q", test.mapName, test.x, test.y, test.z, got, test.want)
+		}
+	}
+	if got := dissect.FloorAt("ClubHouseY10", 4); got != "2F" {
+		t.Errorf("FloorAt(ClubHouseY10, 4) = %q, want the map's 2F", got)
+	}
+	invalid := []string{
+		`{"version": 2, "maps": []}`,
+		`{"version": 1, "maps": [{"map": "Oregon"}]}`,
+		`{"version": 1, "maps": [{"map": "Oregon", "floors": [{"name": "1F", "minZ": 0, "maxZ": 4}, {"name": "B", "minZ": -4, "maxZ": 0}]}]}`,
+		`{"version": 1, "maps": [{"map": "Oregon", "floors": [{"name": "1F", "minZ": 0, "maxZ": 4}], "rooms": [{"name": "Attic", "floor": "3F", "polygon": [[0, 0], [1, 0], [1, 1]]}]}]}`,
+		`{"version": 1, "maps": [{"map": "Oregon", "floors": [{"name": "1F", "minZ": 0, "maxZ": 4}], "rooms": [{"name": "Line", "floor": "1F", "polygon": [[0, 0], [1, 0]]}]}]}`,
+	}
+	for _, in := range invalid {
+		if err := dissect.LoadRooms(strings.NewReader(in)); err == nil {
+			t.Errorf("LoadRooms(%s): expected an error", in)
+		}
+	}
+	if _, ok := dissect.MapGeometryFor("Oregon"); ok {
+		t.Error("invalid geometry was registered for Oregon")
+	}
+}
+
+func TestReader_ValidateRooms(t *testing.T) {
+	if err := dissect.LoadRooms(strings.NewReader(clubhouseRooms)); err != nil {
+		t.Fatalf("LoadRooms(): expected no error, got %v", err)
+	}
+	r, err := dissect.NewReader(bytes.NewReader(mapReplayData(t, dissect.ClubHouseY10, dissect.Bomb, dissect.Y9S1, walkPackets()...)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r.EnableMovementTracking(0)
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	report, err := r.ValidateRooms()
+	if err != nil {
+		t.Fatalf("ValidateRooms(): expected no error, got %v", err)
+	}
+	// Target1 leaves the lobby after walking 10 meters
+	if report.Map != "ClubHouseY10" || report.Positions != 50 || len(report.Outside) != 29 {
+		t.Fatalf("got %s with %d of %d positions outside, want ClubHouseY10 with 29 of 50",
+			report.Map, len(report.Outside), report.Positions)
+	}
+	if p := report.Outside[0]; p.Username != "Target1" || p.X != 10.5 || p.Floor != "1F" {
+		t.Errorf("got first outside position %+v, want Target1 at x 10.5 on 1F", p)
+	}
+
+	r = objectiveReplay(t, dissect.Bomb, dissect.Y9S1, walkPackets()...)
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	if _, err = r.ValidateRooms(); !errors.Is(err, dissect.ErrNoRooms) {
+		t.Errorf("ValidateRooms() on Chalet: got %v, want ErrNoRooms", err)
+	}
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// lobbyExitPackets returns the packets of Target1 walking to the edge of the
+// lobby, stepping out of it for 0.3 seconds, then leaving it after 3 seconds.
+func lobbyExitPackets() [][]byte {
+	xs := make([]float32, 0, 50)
+	for i := range 20 {
+		xs = append(xs, float32(i)*0.5)
+	}
+	xs = append(xs, 10.5, 10.5, 10.5)
+	for range 7 {
+		xs = append(xs, 9.5)
+	}
+	for i := range 10 {
+		xs = append(xs, 10.5+float32(i)*0.5)
+	}
+	for range 10 {
+		xs = append(xs, 15)
+	}
+	packets := [][]byte{timePacket(180)}
+	for i, x := range xs {
+		packets = append(packets, positionPacket(0x1010, 0xB8, 0x01, x, 1, 1, 10))
+		if (i+1)%10 == 0 {
+			packets = append(packets, timePacket(uint32(180-(i+1)/10)))
+		}
+	}
+	return packets
+}
+
+func TestReader_RoomTimeline(t *testing.T) {
+	if err := dissect.LoadRooms(strings.NewReader(clubhouseRooms)); err != nil {
+		t.Fatalf("LoadRooms(): expected no error, got %v", err)
+	}
+	r, err := dissect.NewReader(bytes.NewReader(mapReplayData(t, dissect.ClubHouseY10, dissect.Bomb, dissect.Y9S1, lobbyExitPackets()...)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r.EnableMovementTracking(0)
+	changes := make([]dissect.RoomChangeEvent, 0)
+	dissect.Subscribe(r, func(e dissect.RoomChangeEvent) error {
+		changes = append(changes, e)
+		return nil
+	})
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	movements := r.GetMovementData()
+	if len(movements) != 1 || movements[0].Positions[0].Room != "1F Lobby" || movements[0].Positions[49].Room != "1F" {
+		t.Fatalf("got movements %+v, want Target1 positions labeled from 1F Lobby to 1F", movements)
+	}
+	timeline := r.RoomTimeline()
+	if len(timeline) != 1 || timeline[0].Username != "Target1" || len(timeline[0].Visits) != 2 {
+		t.Fatalf("got room timeline %+v, want Target1 visiting 2 rooms", timeline)
+	}
+	near := func(name string, got, want float64) {
+		if math.Abs(got-want) > 1e-6 {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+	lobby, floor := timeline[0].Visits[0], timeline[0].Visits[1]
+	if lobby.Room != "1F Lobby" || floor.Room != "1F" {
+		t.Errorf("got visits %s, %s, want 1F Lobby, 1F", lobby.Room, floor.Room)
+	}
+	near("lobby enter", lobby.EnterElapsed, 0)
+	near("lobby leave", lobby.LeaveElapsed, 3)
+	near("lobby duration", lobby.Duration(), 3)
+	near("1F enter", floor.EnterElapsed, 3)
+	near("1F enter time", floor.EnterTime, 177)
+	near("1F leave", floor.LeaveElapsed, 4.9)
+	if len(changes) != 1 {
+		t.Fatalf("got %d room changes, want 1", len(changes))
+	}
+	if c := changes[0]; c.Username != "Target1" || c.From != "1F Lobby" || c.To != "1F" || math.Abs(c.ElapsedSeconds-3) > 1e-6 {
+		t.Errorf("got room change %+v, want Target1 from 1F Lobby to 1F at 3s", c)
+	}
+}
+
+func TestReader_RoomChangeHandlerError(t *testing.T) {
+	if err := dissect.LoadRooms(strings.NewReader(clubhouseRooms)); err != nil {
+		t.Fatalf("LoadRooms(): expected no error, got %v", err)
+	}
+	r, err := dissect.NewReader(bytes.NewReader(mapReplayData(t, dissect.ClubHouseY10, dissect.Bomb, dissect.Y9S1, lobbyExitPackets()...)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r.EnableMovementTracking(0)
+	stop := errors.New("stop")
+	dissect.Subscribe(r, func(e dissect.RoomChangeEvent) error {
+		return stop
+	})
+	if err = r.Read(); !errors.Is(err, stop) {
+		t.Errorf("Read(): expected handler error, got %v", err)
+	}
+}
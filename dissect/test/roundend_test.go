@@ -0,0 +1,96 @@
+package test
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// uiIDSuffix is appended to player packets of Y9S3+ replays.
+var uiIDSuffix = append(append([]byte{0x38, 0xDF, 0xEE, 0x88}, make([]byte, 13)...), 0x08, 1, 0, 0, 0, 0, 0, 0, 0)
+
+func TestReader_RoundEnd(t *testing.T) {
+	attackers := []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}
+	defenders := []string{"Target1", "Target2", "Target3", "Target4", "Target5"}
+	for _, code := range []int{dissect.Y9S1, dissect.Y9S4} {
+		packets := make([][]byte, 0)
+		for i, name := range append(attackers, defenders...) {
+			op := dissect.Ash
+			if i >= len(attackers) {
+				op = dissect.Rook
+			}
+			p := playerPacket(name, op, byte(i+1))
+			if code >= dissect.Y9S3 {
+				p = append(p, uiIDSuffix...)
+			}
+			packets = append(packets, p)
+		}
+		packets = append(packets, timePacket(120))
+		for i, target := range defenders {
+			packets = append(packets, timePacket(uint32(100-i)), killPacketFor(code, "Alpha", target, killTypeKill, false))
+		}
+		// the replay continues after the round is decided
+		packets = append(packets, timePacket(90), timePacket(89))
+		props := teamProps(attackers, defenders)
+		for i := range props {
+			switch props[i][0] {
+			case "code":
+				props[i][1] = strconv.Itoa(code)
+			case "teamscore0":
+				props[i][1] = "1"
+			}
+		}
+		if code >= dissect.Y9S4 {
+			last := props[len(props)-1]
+			props = append(props[:len(props)-1], [2]string{"startingteamscore0", "0"}, [2]string{"startingteamscore1", "0"}, last)
+		}
+		r, err := dissect.NewReader(bytes.NewReader(buildReplay(t, props, [][]byte{join(packets...)}, true)))
+		if err != nil {
+			t.Fatalf("code %d: expected no error, got %v", code, err)
+		}
+		events := 0
+		dissect.Subscribe(r, func(e dissect.RoundEndEvent) error {
+			events++
+			return nil
+		})
+		if err = r.Read(); !dissect.Ok(err) {
+			t.Fatalf("code %d: Read(): expected no error, got %v", code, err)
+		}
+		if events != 1 {
+			t.Errorf("code %d: got %d RoundEndEvents, want 1", code, events)
+		}
+		u := r.MatchFeedback[len(r.MatchFeedback)-1]
+		if u.Type != dissect.RoundEnd {
+			t.Fatalf("code %d: last update is %v, want RoundEnd", code, u.Type)
+		}
+		if u.Role != dissect.Attack || u.WinCondition != dissect.KilledOpponents {
+			t.Errorf("code %d: got %s won by %s, want Attack won by KilledOpponents", code, u.Role, u.WinCondition)
+		}
+		if u.Time != "1:36" || len(u.Alive) != 2 || u.Alive[0] != 5 || u.Alive[1] != 0 {
+			t.Errorf("code %d: got round end at %s with alive %v, want 1:36 with [5 0]", code, u.Time, u.Alive)
+		}
+	}
+}
+
+func TestReader_RoundEndTime(t *testing.T) {
+	for _, last := range []uint32{0, 1} {
+		r := objectiveReplay(t, dissect.Bomb, dissect.Y9S1, timePacket(3), timePacket(2), timePacket(1), timePacket(last))
+		if err := r.Read(); !dissect.Ok(err) {
+			t.Fatalf("Read(): expected no error, got %v", err)
+		}
+		u := r.MatchFeedback[len(r.MatchFeedback)-1]
+		if u.Type != dissect.RoundEnd || u.WinCondition != dissect.Time || u.Role != dissect.Defense {
+			t.Fatalf("clock at %d: got %v won by %s %s, want RoundEnd won by Defense Time", last, u.Type, u.Role, u.WinCondition)
+		}
+		// the round is only known to end at 0:00 if the clock ran out
+		want := ""
+		if last == 0 {
+			want = "0:00"
+		}
+		if u.Time != want || u.TimeInSeconds != 0 {
+			t.Errorf("clock at %d: got round end at %q (%v), want %q", last, u.Time, u.TimeInSeconds, want)
+		}
+	}
+}
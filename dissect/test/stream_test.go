@@ -0,0 +1,87 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+var testMarker = []byte{0xA1, 0xB2, 0xC3, 0xD4}
+
+// markerBody returns n random body sections containing numbered test markers.
+func markerBody(n int, size int) (sections [][]byte, markers int) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		section := make([]byte, 0, size)
+		for len(section) < size {
+			if rng.Intn(200) == 0 {
+				section = append(section, testMarker...)
+				section = binary.LittleEndian.AppendUint32(section, uint32(markers))
+				markers++
+				continue
+			}
+			section = append(section, byte(0x10+rng.Intn(8)))
+		}
+		sections = append(sections, section)
+	}
+	return
+}
+
+func collectMarkers(t *testing.T, open func([]byte) (*dissect.Reader, error), replay []byte) []uint32 {
+	t.Helper()
+	r, err := open(replay)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got := make([]uint32, 0)
+	r.Listen(testMarker, func(r *dissect.Reader) error {
+		b, err := r.Bytes(4)
+		if err != nil {
+			return err
+		}
+		got = append(got, binary.LittleEndian.Uint32(b))
+		return nil
+	})
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	return got
+}
+
+func TestNewStreamReader(t *testing.T) {
+	sections, markers := markerBody(12, 100*1024)
+	for _, chunked := range []bool{true, false} {
+		replay := buildReplay(t, replayProps, sections, chunked)
+		want := collectMarkers(t, func(b []byte) (*dissect.Reader, error) {
+			return dissect.NewReader(bytes.NewReader(b))
+		}, replay)
+		got := collectMarkers(t, func(b []byte) (*dissect.Reader, error) {
+			return dissect.NewStreamReader(bytes.NewReader(b))
+		}, replay)
+		if len(want) != markers {
+			t.Errorf("chunked=%v: NewReader found %d markers, want %d", chunked, len(want), markers)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("chunked=%v: NewStreamReader found %d markers, want %d", chunked, len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("chunked=%v: marker %d = %d, want %d", chunked, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestNewStreamReader_Header(t *testing.T) {
+	sections, _ := markerBody(2, 1024)
+	r, err := dissect.NewStreamReader(bytes.NewReader(buildReplay(t, replayProps, sections, true)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if r.Header.CodeVersion != dissect.Y9S1 || r.Header.Map != dissect.Chalet {
+		t.Errorf("unexpected header: code=%d map=%s", r.Header.CodeVersion, r.Header.Map)
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// playerPacket returns a Y9S1 player packet without a profile id.
+func playerPacket(username string, op dissect.Operator, id byte) []byte {
+	b := []byte{0x22, 0x07, 0x94, 0x9B, 0xDC, byte(len(username))}
+	b = append(b, username...)
+	b = append(b, 0x40, 0xF2, 0x15, 0x04)
+	b = append(b, make([]byte, 9)...) // swap check
+	b = append(b, 0x08)
+	b = binary.LittleEndian.AppendUint64(b, uint64(op))
+	b = append(b, 0x22)
+	b = append(b, 0x33, 0xD8, 0x3D, 0x4F, 0x23, id, 0x00, 0x00, 0x00)
+	b = append(b, 0xAF, 0x98, 0x99, 0xCA, 0x05)
+	return append(b, "Spawn"...)
+}
+
+func TestReader_TeamKills(t *testing.T) {
+	attackers := []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}
+	defenders := []string{"Target1", "Target2", "Target3", "Target4", "Target5"}
+	packets := make([][]byte, 0)
+	for i, name := range append(attackers, defenders...) {
+		op := dissect.Ash
+		if i >= len(attackers) {
+			op = dissect.Rook
+		}
+		packets = append(packets, playerPacket(name, op, byte(i+1)))
+	}
+	packets = append(packets,
+		timePacket(170),
+		killPacket("Alpha", "Target1", killTypeKill, true),
+		killPacket("Alpha", "Bravo", killTypeKill, true),
+		killPacket("Target2", "Target2", killTypeKill, false),
+		killPacket("Charlie", "Delta", killTypeDBNO, false),
+	)
+	props := teamProps(attackers, defenders)
+	r, err := dissect.NewReader(bytes.NewReader(buildReplay(t, props, [][]byte{join(packets...)}, true)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err = r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	want := []struct {
+		teamKill bool
+		suicide  bool
+	}{{false, false}, {true, false}, {false, true}, {true, false}, {false, false}}
+	if len(r.MatchFeedback) != len(want) {
+		t.Fatalf("got %d match updates, want %d: %+v", len(r.MatchFeedback), len(want), r.MatchFeedback)
+	}
+	for i, w := range want {
+		u := r.MatchFeedback[i]
+		if u.TeamKill != w.teamKill || u.Suicide != w.suicide {
+			t.Errorf("update %d (%s -> %s): got teamKill=%v suicide=%v, want teamKill=%v suicide=%v",
+				i, u.Username, u.Target, u.TeamKill, u.Suicide, w.teamKill, w.suicide)
+		}
+	}
+	if k := r.OpeningKill(); k.Target != "Target1" {
+		t.Errorf("got opening kill of %q, want Target1", k.Target)
+	}
+	stats := make(map[string]dissect.PlayerRoundStats)
+	for _, s := range r.PlayerStats() {
+		stats[s.Username] = s
+	}
+	if s := stats["Alpha"]; s.Kills != 1 || s.TeamKills != 1 || s.Headshots != 1 {
+		t.Errorf("unexpected stats for Alpha: %+v", s)
+	}
+	if s := stats["Target2"]; s.Kills != 0 || s.TeamKills != 0 || !s.Died {
+		t.Errorf("unexpected stats for Target2: %+v", s)
+	}
+	if !stats["Bravo"].Died {
+		t.Error("Bravo did not die")
+	}
+}
+
+func TestReader_SelfDownFinishedByEnemy(t *testing.T) {
+	r := objectiveReplay(t, dissect.Bomb, dissect.Y9S1,
+		timePacket(170),
+		killPacket("Target1", "Target1", killTypeDBNO, false),
+		killPacket("Alpha", "Target1", killTypeKill, false),
+	)
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	i := slices.IndexFunc(r.MatchFeedback, func(u dissect.MatchUpdate) bool { return u.Type == dissect.Kill })
+	if i < 0 {
+		t.Fatalf("got no kill in %+v", r.MatchFeedback)
+	}
+	u := r.MatchFeedback[i]
+	if u.Username != "Target1" || u.Finisher != "Alpha" || !u.Suicide || u.TeamKill {
+		t.Errorf("got kill %s -> %s finished by %s, suicide=%v teamKill=%v, want a suicide of Target1 finished by Alpha",
+			u.Username, u.Target, u.Finisher, u.Suicide, u.TeamKill)
+	}
+	for _, s := range r.PlayerStats() {
+		if s.Username == "Target1" && (s.Kills != 0 || s.TeamKills != 0 || !s.Died) {
+			t.Errorf("unexpected stats for Target1: %+v", s)
+		}
+	}
+}
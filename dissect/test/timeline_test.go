@@ -0,0 +1,57 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestReader_Timeline(t *testing.T) {
+	// Target1 walks 5 meters per second with 10 positions per clock tick
+	packets := [][]byte{timePacket(180)}
+	for i := range 40 {
+		packets = append(packets, positionPacket(0x1010, 0xB8, 0x01, float32(i)*0.5, 1, 1, 10))
+		if (i+1)%10 == 0 {
+			packets = append(packets, timePacket(uint32(180-(i+1)/10)))
+		}
+		if i == 19 {
+			packets = append(packets, killPacket("Alpha", "Target1", killTypeKill, false))
+		}
+	}
+	r := objectiveReplay(t, dissect.Bomb, dissect.Y9S1, packets...)
+	r.EnableMovementTracking(0)
+	if err := r.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	tl := r.Timeline()
+	if r.Timeline() != tl {
+		t.Error("Timeline(): got a new timeline after Read")
+	}
+	if tl.Start() != 0 || tl.End() != 3.9 {
+		t.Errorf("got timeline %v-%v, want 0-3.9", tl.Start(), tl.End())
+	}
+	if elapsed, ok := tl.ElapsedAt(178); !ok || elapsed != 2 {
+		t.Errorf("ElapsedAt(178): got %v %v, want 2 true", elapsed, ok)
+	}
+	s := r.SnapshotAt(1.25)
+	if s.TimeInSeconds != 178.75 || len(s.Players) != 1 {
+		t.Fatalf("got snapshot at %v with %d players, want 178.75 with 1", s.TimeInSeconds, len(s.Players))
+	}
+	p := s.Players[0]
+	if p.Username != "Target1" || p.X != 6.25 || p.Floor != "1F" || !p.Alive {
+		t.Errorf("got %+v, want Target1 alive at x 6.25 on 1F", p)
+	}
+	if p := r.SnapshotAt(2.5).Players[0]; p.Alive {
+		t.Errorf("got Target1 alive after their death")
+	}
+	n := 0
+	for s := range r.Snapshots(2) {
+		if want := float64(n) / 2; s.ElapsedSeconds != want {
+			t.Errorf("snapshot %d: got elapsed %v, want %v", n, s.ElapsedSeconds, want)
+		}
+		n++
+	}
+	if n != 8 {
+		t.Errorf("got %d snapshots, want 8", n)
+	}
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+type viewerData struct {
+	Title   string `json:"title"`
+	Players []struct {
+		Username  string       `json:"username"`
+		Team      string       `json:"team"`
+		Positions [][5]float64 `json:"positions"`
+	} `json:"players"`
+	Rooms []struct {
+		Name string `json:"name"`
+	} `json:"rooms"`
+	Events []struct {
+		Elapsed float64 `json:"elapsed"`
+		Type    string  `json:"type"`
+	} `json:"events"`
+	Clock []float64 `json:"clock"`
+}
+
+// viewerRounds returns the rounds embedded in a replay viewer page.
+func viewerRounds(t *testing.T, page string) []viewerData {
+	t.Helper()
+	_, data, ok := strings.Cut(page, "const rounds = ")
+	data, _, ok2 := strings.Cut(data, " || [];")
+	if !ok || !ok2 {
+		t.Fatal("no rounds embedded in the viewer")
+	}
+	var rounds []viewerData
+	if err := json.Unmarshal([]byte(data), &rounds); err != nil {
+		t.Fatalf("invalid embedded rounds: %v", err)
+	}
+	return rounds
+}
+
+func TestReader_WriteHTML(t *testing.T) {
+	r := lobbyReplay(t)
+	var out bytes.Buffer
+	if err := r.WriteHTML(&out); err != nil {
+		t.Fatalf("WriteHTML(): expected no error, got %v", err)
+	}
+	page := out.String()
+	if !strings.Contains(page, "<title>ClubHouseY10 Round 1</title>") {
+		t.Error("missing the round title")
+	}
+	if strings.Contains(page, "http://") || strings.Contains(page, "https://") {
+		t.Error("the viewer should not load anything from the network")
+	}
+	rounds := viewerRounds(t, page)
+	if len(rounds) != 1 {
+		t.Fatalf("got %d rounds, want 1", len(rounds))
+	}
+	v := rounds[0]
+	if len(v.Players) != 10 {
+		t.Fatalf("got %d players, want 10", len(v.Players))
+	}
+	for _, p := range v.Players {
+		want := 0
+		if p.Username == "Target1" {
+			want = 50
+		}
+		if len(p.Positions) != want {
+			t.Errorf("%s: got %d positions, want %d", p.Username, len(p.Positions), want)
+		}
+	}
+	if p := v.Players[5].Positions; len(p) > 20 && p[20] != [5]float64{2, 10.5, 1, 1, 0} {
+		t.Errorf("got position %v, want [2 10.5 1 1 0]", p[20])
+	}
+	if len(v.Rooms) != 1 || v.Rooms[0].Name != "1F Lobby" {
+		t.Errorf("got rooms %+v, want the lobby", v.Rooms)
+	}
+	kill := false
+	for _, e := range v.Events {
+		kill = kill || (e.Type == "Kill" && e.Elapsed == 5)
+	}
+	if !kill {
+		t.Errorf("got events %+v, want a kill at 5s", v.Events)
+	}
+	if len(v.Clock) != 6 || v.Clock[0] != 180 || v.Clock[5] != 175 {
+		t.Errorf("got clock %v, want 180 to 175", v.Clock)
+	}
+}
+
+func TestMatchReader_WriteHTML(t *testing.T) {
+	dir := t.TempDir()
+	replay := objectiveReplayData(t, dissect.Bomb, dissect.Y9S1, walkPackets()...)
+	for i := 1; i <= 2; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("Match-R%02d.rec", i)), replay, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	m, err := dissect.NewMatchReader(in)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	m.TrackMovement = true
+	if err = m.Read(); !dissect.Ok(err) {
+		t.Fatalf("Read(): expected no error, got %v", err)
+	}
+	var out bytes.Buffer
+	if err = m.WriteHTML(&out); err != nil {
+		t.Fatalf("WriteHTML(): expected no error, got %v", err)
+	}
+	if rounds := viewerRounds(t, out.String()); len(rounds) != 2 {
+		t.Errorf("got %d rounds, want 2", len(rounds))
+	}
+}
@@ -13,9 +13,7 @@ func readTime(r *Reader) error {
 	if err != nil {
 		return err
 	}
-	r.time = float64(time)
-	r.timeRaw = fmt.Sprintf("%d:%02d", time/60, time%60)
-	return nil
+	return r.setTime(float64(time), fmt.Sprintf("%d:%02d", time/60, time%60))
 }
 
 func readY7Time(r *Reader) error {
@@ -26,9 +24,7 @@ func readY7Time(r *Reader) error {
 		if err != nil {
 			return err
 		}
-		r.time = seconds
-		r.timeRaw = parts[0]
-		return nil
+		return r.setTime(seconds, parts[0])
 	}
 	minutes, err := strconv.Atoi(parts[0])
 	if err != nil {
@@ -38,13 +34,33 @@ func readY7Time(r *Reader) error {
 	if err != nil {
 		return err
 	}
-	r.time = float64((minutes * 60) + seconds)
-	r.timeRaw = time
-	return nil
+	return r.setTime(float64((minutes*60)+seconds), time)
+}
+
+// setTime updates the round clock, emitting a TimeTickEvent when it changes.
+func (r *Reader) setTime(seconds float64, raw string) error {
+	changed := seconds != r.time || raw != r.timeRaw
+	if changed {
+		r.recordTick(seconds)
+	}
+	r.time = seconds
+	r.timeRaw = raw
+	if !changed {
+		return nil
+	}
+	if err := r.emit(TimeTickEvent{Time: raw, TimeInSeconds: seconds}); err != nil {
+		return err
+	}
+	return r.expireDowns()
 }
 
-func (r *Reader) roundEnd() {
+func (r *Reader) roundEnd() (err error) {
 	log.Debug().Msg("round_end")
+	defer func() {
+		if err == nil {
+			err = r.recordRoundEnd()
+		}
+	}()
 
 	planter := -1
 	disabler := -1
@@ -58,11 +74,7 @@ func (r *Reader) roundEnd() {
 		roles[p.TeamIndex] = r.Header.Teams[p.TeamIndex].Role
 	}
 
-	if r.Header.CodeVersion >= Y9S4 {
-		team0Won := r.Header.Teams[0].StartingScore < r.Header.Teams[0].Score
-		r.Header.Teams[0].Won = team0Won
-		r.Header.Teams[1].Won = !team0Won
-	}
+	r.Header.deriveWinner()
 
 	for _, u := range r.MatchFeedback {
 		switch u.Type {
@@ -74,7 +86,7 @@ func (r *Reader) roundEnd() {
 				u.Username = u.usernameFromScoreboard
 			}
 			break
-		case Death:
+		case Death, BleedOut:
 			i := r.Header.Players[r.PlayerIndexByUsername(u.Username)].TeamIndex
 			deaths[i] = deaths[i] + 1
 			break
@@ -89,6 +101,7 @@ func (r *Reader) roundEnd() {
 			playerIdx := r.PlayerIndexByUsername(u.Username)
 			if playerIdx < 0 || playerIdx >= len(r.Header.Players) {
 				log.Debug().Msg("warn: defuser disable player not found")
+				r.diagnose(SeverityWarning, "defuser disable player not found")
 				return
 			}
 			i := r.Header.Players[playerIdx].TeamIndex
@@ -109,6 +122,10 @@ func (r *Reader) roundEnd() {
 		}
 	}
 
+	if r.objectiveEnd() {
+		return
+	}
+
 	// Infer DefuserDisableComplete when plant happened but no disable was recorded
 	// and the defense team won (Y9S4+ provides reliable win info in the header)
 	if r.Header.CodeVersion >= Y9S4 && planter > -1 && !hasDisableComplete {
@@ -130,7 +147,9 @@ func (r *Reader) roundEnd() {
 				Time:          r.timeRaw,
 				TimeInSeconds: r.time,
 			}
-			r.MatchFeedback = append(r.MatchFeedback, u)
+			if err = r.addFeedback(u); err != nil {
+				return err
+			}
 			log.Debug().Interface("match_update", u).Msg("inferred DefuserDisableComplete")
 			r.Header.Teams[defenseTeamIndex].WinCondition = DisabledDefuser
 			return
@@ -212,4 +231,5 @@ func (r *Reader) roundEnd() {
 
 	r.Header.Teams[i].Won = true
 	r.Header.Teams[i].WinCondition = Time
+	return
 }
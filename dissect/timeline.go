@@ -0,0 +1,200 @@
+package dissect
+
+import (
+	"iter"
+	"math"
+	"sort"
+)
+
+// PlayerState is where a player was at a moment of the round.
+type PlayerState struct {
+	Username string  `json:"username"`
+	Operator string  `json:"operator"`
+	Team     string  `json:"team"` // "Attack" or "Defense"
+	Alive    bool    `json:"alive"`
+	X        float32 `json:"x"`
+	Y        float32 `json:"y"`
+	Z        float32 `json:"z"`
+	Yaw      float32 `json:"yaw"` // degrees
+	Floor    string  `json:"floor"`
+	Room     string  `json:"room,omitempty"`
+}
+
+// Snapshot is the state of every tracked player at a moment of the round.
+type Snapshot struct {
+	TimeInSeconds  float64       `json:"timeInSeconds"`  // round clock, as in MatchUpdate
+	ElapsedSeconds float64       `json:"elapsedSeconds"` // seconds since the first clock tick of the replay
+	Players        []PlayerState `json:"players"`
+}
+
+// Timeline answers where each player was at any moment of a round by
+// interpolating between their tracked positions. Create one with
+// Reader.Timeline after a Read with movement tracking enabled.
+type Timeline struct {
+	clock     packetClock
+	mapName   string
+	movements []PlayerMovement
+	deaths    map[string]float64 // username -> elapsed seconds of their death
+	start     float64
+	end       float64
+}
+
+// Timeline returns the Timeline of the round, built from its movement data.
+// The Timeline is empty unless movement tracking was enabled during Read.
+// It is built once Read finishes and shared by later calls.
+func (r *Reader) Timeline() *Timeline {
+	if r.timeline != nil {
+		return r.timeline
+	}
+	return r.newTimeline()
+}
+
+// newTimeline builds the Timeline of the round from its movement data.
+func (r *Reader) newTimeline() *Timeline {
+	tl := &Timeline{
+		clock:   r.newPacketClock(),
+		mapName: r.Header.Map.String(),
+		deaths:  make(map[string]float64),
+	}
+	movements := r.GetMovementData()
+	// keep the header order of the players
+	for _, p := range r.Header.Players {
+		for _, m := range movements {
+			if m.Username == p.Username && len(m.Positions) > 0 {
+				tl.movements = append(tl.movements, m)
+			}
+		}
+	}
+	for i, m := range tl.movements {
+		first, last := m.Positions[0].ElapsedSeconds, m.Positions[len(m.Positions)-1].ElapsedSeconds
+		if i == 0 || first < tl.start {
+			tl.start = first
+		}
+		if i == 0 || last > tl.end {
+			tl.end = last
+		}
+	}
+	for _, u := range r.MatchFeedback {
+		if victim, ok := u.victim(); ok {
+			if elapsed, found := tl.clock.elapsedAt(u.TimeInSeconds); found {
+				tl.deaths[victim] = elapsed
+			}
+		}
+	}
+	return tl
+}
+
+// Start returns the elapsed seconds of the first tracked position.
+func (tl *Timeline) Start() float64 {
+	return tl.start
+}
+
+// End returns the elapsed seconds of the last tracked position.
+func (tl *Timeline) End() float64 {
+	return tl.end
+}
+
+// ElapsedAt returns the elapsed seconds when the round clock showed the time,
+// such as the TimeInSeconds of a MatchUpdate. The clock shows the same times
+// in the prep and action phases, so the action phase is preferred.
+func (tl *Timeline) ElapsedAt(timeInSeconds float64) (float64, bool) {
+	return tl.clock.elapsedAt(timeInSeconds)
+}
+
+// At returns the state of every tracked player the elapsed seconds after
+// the first clock tick. Players hold their first and last positions
+// before and after they were tracked.
+func (tl *Timeline) At(elapsed float64) Snapshot {
+	s := Snapshot{
+		TimeInSeconds:  tl.clock.clockAt(elapsed),
+		ElapsedSeconds: elapsed,
+		Players:        make([]PlayerState, 0, len(tl.movements)),
+	}
+	for _, m := range tl.movements {
+		pos := interpolatePosition(m.Positions, elapsed)
+		death, died := tl.deaths[m.Username]
+		s.Players = append(s.Players, PlayerState{
+			Username: m.Username,
+			Operator: m.Operator,
+			Team:     m.Team,
+			Alive:    !died || elapsed < death,
+			X:        pos.X,
+			Y:        pos.Y,
+			Z:        pos.Z,
+			Yaw:      pos.Yaw,
+			Floor:    FloorAt(tl.mapName, pos.Z),
+			Room:     GetRoomAtPosition(tl.mapName, pos.X, pos.Y, pos.Z),
+		})
+	}
+	return s
+}
+
+// Snapshots returns an iterator over the snapshots of the tracked part of
+// the round, tickRate times per second.
+func (tl *Timeline) Snapshots(tickRate float64) iter.Seq[Snapshot] {
+	return func(yield func(Snapshot) bool) {
+		if tickRate <= 0 || len(tl.movements) == 0 {
+			return
+		}
+		for i := 0; ; i++ {
+			elapsed := tl.start + float64(i)/tickRate
+			if elapsed > tl.end {
+				return
+			}
+			if !yield(tl.At(elapsed)) {
+				return
+			}
+		}
+	}
+}
+
+// interpolatePosition returns the position between the two positions around
+// the elapsed seconds. positions must be ordered by ElapsedSeconds.
+func interpolatePosition(positions []PlayerPosition, elapsed float64) PlayerPosition {
+	i := sort.Search(len(positions), func(i int) bool {
+		return positions[i].ElapsedSeconds > elapsed
+	})
+	if i == 0 {
+		return positions[0]
+	}
+	if i == len(positions) {
+		return positions[i-1]
+	}
+	a, b := positions[i-1], positions[i]
+	span := b.ElapsedSeconds - a.ElapsedSeconds
+	if span <= 0 {
+		return a
+	}
+	f := float32((elapsed - a.ElapsedSeconds) / span)
+	return PlayerPosition{
+		ElapsedSeconds: elapsed,
+		X:              a.X + f*(b.X-a.X),
+		Y:              a.Y + f*(b.Y-a.Y),
+		Z:              a.Z + f*(b.Z-a.Z),
+		Yaw:            interpolateYaw(a.Yaw, b.Yaw, f),
+	}
+}
+
+// interpolateYaw interpolates between two angles in degrees the short way around.
+func interpolateYaw(a, b, f float32) float32 {
+	d := float32(math.Mod(float64(b-a)+540, 360) - 180)
+	yaw := a + f*d
+	if yaw > 180 {
+		yaw -= 360
+	} else if yaw <= -180 {
+		yaw += 360
+	}
+	return yaw
+}
+
+// SnapshotAt returns the state of every tracked player the elapsed seconds
+// after the first clock tick. See Timeline.At.
+func (r *Reader) SnapshotAt(elapsed float64) Snapshot {
+	return r.Timeline().At(elapsed)
+}
+
+// Snapshots returns an iterator over the snapshots of the round, tickRate
+// times per second. See Timeline.Snapshots.
+func (r *Reader) Snapshots(tickRate float64) iter.Seq[Snapshot] {
+	return r.Timeline().Snapshots(tickRate)
+}
@@ -18,6 +18,7 @@ func (r *Reader) PlayerIndexByID(id []byte) int {
 	}
 	if !bytes.Equal(id, []byte{0x00, 0x00, 0x00, 0x00}) {
 		log.Debug().Hex("id", id).Msg("warn: could not index player by id")
+		r.diagnose(SeverityInfo, fmt.Sprintf("could not index player by id %X", id))
 	}
 	return -1
 }
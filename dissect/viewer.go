@@ -0,0 +1,139 @@
+package dissect
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+)
+
+//go:embed viewer.html
+var viewerHTML string
+
+var viewerTemplate = template.Must(template.New("viewer").Parse(viewerHTML))
+
+// viewerRound is the data of a round played back by the viewer.
+// Times are elapsed seconds since the first clock tick of the round.
+type viewerRound struct {
+	Title   string         `json:"title"`
+	Map     string         `json:"map"`
+	Teams   [2]Team        `json:"teams"`
+	Floors  []Floor        `json:"floors"`
+	Rooms   []Room         `json:"rooms"`
+	Players []viewerPlayer `json:"players"`
+	Events  []viewerEvent  `json:"events"`
+	Clock   []float64      `json:"clock"` // round clock at each second since Start
+	Start   float64        `json:"start"`
+	End     float64        `json:"end"`
+}
+
+type viewerPlayer struct {
+	Username  string       `json:"username"`
+	Operator  string       `json:"operator"`
+	Team      string       `json:"team"`      // "Attack" or "Defense"
+	Positions [][5]float64 `json:"positions"` // elapsed, x, y, z, yaw
+}
+
+type viewerEvent struct {
+	Elapsed  float64 `json:"elapsed"`
+	Time     string  `json:"time"`
+	Type     string  `json:"type"`
+	Username string  `json:"username,omitempty"`
+	Target   string  `json:"target,omitempty"`
+	Headshot bool    `json:"headshot,omitempty"`
+	Message  string  `json:"message,omitempty"`
+}
+
+// WriteHTML writes a self-contained HTML page playing back the round on a
+// top-down view of each floor, with the kill feed, defuser timer and round
+// clock. Enable movement tracking before Read to include the players.
+func (r *Reader) WriteHTML(out io.Writer) error {
+	return writeViewer(out, fmt.Sprintf("%s Round %d", r.Header.Map, r.Header.RoundNumber+1), []*Reader{r})
+}
+
+// WriteHTML writes a self-contained HTML page playing back every round of the
+// match read so far. Enable TrackMovement before reading. See Reader.WriteHTML.
+func (m *MatchReader) WriteHTML(out io.Writer) error {
+	rounds := make([]*Reader, 0, len(m.rounds))
+	for i := range m.rounds {
+		if r := m.round(i); r != nil {
+			rounds = append(rounds, r)
+		}
+	}
+	title := "Match"
+	if len(rounds) > 0 {
+		title = fmt.Sprintf("%s Match", rounds[0].Header.Map)
+	}
+	return writeViewer(out, title, rounds)
+}
+
+func writeViewer(out io.Writer, title string, rounds []*Reader) error {
+	data := struct {
+		Title  string
+		Rounds []viewerRound
+	}{Title: title}
+	for _, r := range rounds {
+		data.Rounds = append(data.Rounds, r.viewerRound())
+	}
+	return viewerTemplate.Execute(out, data)
+}
+
+func (r *Reader) viewerRound() viewerRound {
+	mapName := r.Header.Map.String()
+	tl := r.Timeline()
+	v := viewerRound{
+		Title:   fmt.Sprintf("Round %d", r.Header.RoundNumber+1),
+		Map:     mapName,
+		Teams:   r.Header.Teams,
+		Floors:  mapFloors(mapName),
+		Rooms:   make([]Room, 0),
+		Players: make([]viewerPlayer, 0, len(r.Header.Players)),
+		Events:  make([]viewerEvent, 0, len(r.MatchFeedback)),
+		Start:   tl.Start(),
+		End:     tl.End(),
+	}
+	if g, ok := MapGeometryFor(mapName); ok {
+		v.Rooms = g.Rooms
+	}
+	round := func(f float32) float64 {
+		return math.Round(float64(f)*100) / 100
+	}
+	positions := make(map[string][]PlayerPosition)
+	for _, m := range tl.movements {
+		positions[m.Username] = m.Positions
+	}
+	// untracked players are kept for their team in the kill feed
+	for _, hp := range r.Header.Players {
+		p := viewerPlayer{Username: hp.Username, Operator: hp.Operator.String(), Positions: make([][5]float64, 0)}
+		if hp.TeamIndex >= 0 && hp.TeamIndex < len(r.Header.Teams) {
+			p.Team = string(r.Header.Teams[hp.TeamIndex].Role)
+		}
+		for _, pos := range positions[hp.Username] {
+			p.Positions = append(p.Positions, [5]float64{
+				math.Round(pos.ElapsedSeconds*100) / 100, round(pos.X), round(pos.Y), round(pos.Z), round(pos.Yaw),
+			})
+		}
+		v.Players = append(v.Players, p)
+	}
+	for _, u := range r.MatchFeedback {
+		elapsed, found := tl.ElapsedAt(u.TimeInSeconds)
+		if !found {
+			continue
+		}
+		v.Events = append(v.Events, viewerEvent{
+			Elapsed:  elapsed,
+			Time:     u.Time,
+			Type:     u.Type.String(),
+			Username: u.Username,
+			Target:   u.Target,
+			Headshot: u.Headshot != nil && *u.Headshot,
+			Message:  u.Message,
+		})
+		v.Start, v.End = min(v.Start, elapsed), max(v.End, elapsed)
+	}
+	for t := v.Start; t <= v.End; t++ {
+		v.Clock = append(v.Clock, tl.clock.clockAt(t))
+	}
+	return v
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/redraskal/r6-dissect/dissect"
@@ -21,6 +27,7 @@ type OutputFormat = string
 const (
 	JSON  OutputFormat = "json"
 	Excel OutputFormat = "excel"
+	HTML  OutputFormat = "html"
 )
 
 func main() {
@@ -31,11 +38,29 @@ func main() {
 		log.Fatal().Err(err).Send()
 	}
 	defer in.Close()
+	if viper.GetString("command") == "anonymize" {
+		if err := anonymize(in); err != nil {
+			log.Fatal().Err(err).Send()
+		}
+		return
+	}
+	if viper.GetString("command") == "heatmap" {
+		if err := writeHeatmaps(in); err != nil {
+			log.Fatal().Err(err).Send()
+		}
+		return
+	}
 	out, err := viperFileOrDefault("output", os.Stdout, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
 	if err != nil {
 		log.Fatal().Err(err).Send()
 	}
 	defer out.Close()
+	if viper.GetString("command") == "rooms" {
+		if err := validateRooms(in, out); err != nil {
+			log.Fatal().Err(err).Send()
+		}
+		return
+	}
 	stat, err := in.Stat()
 	if err != nil {
 		log.Fatal().Err(err).Send()
@@ -65,14 +90,14 @@ func main() {
 	if format == Excel {
 		log.Fatal().Msg("Dissect will only export a match folder to Excel.")
 	}
-	if err = writeRound(in, out); err != nil {
+	if err = writeRound(in, format, out); err != nil {
 		log.Fatal().Err(err).Send()
 	}
 }
 
 func setup() {
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-	pflag.StringP("format", "f", "", "specifies the output format (json, excel)")
+	pflag.StringP("format", "f", "", "specifies the output format (json, excel, html)")
 	pflag.StringP("output", "o", "", "specifies the output path")
 	pflag.BoolP("debug", "d", false, "sets log level to debug")
 	pflag.BoolP("dump", "p", false, "dumps decompressed replay to the output")
@@ -80,6 +105,19 @@ func setup() {
 	pflag.BoolP("version", "v", false, "prints the version")
 	pflag.Bool("movement", false, "enables player movement tracking (experimental)")
 	pflag.Int("movement-sample", 10, "movement sample rate (0=all, N=every Nth position)")
+	pflag.Bool("stream", false, "decompresses replays incrementally to reduce memory usage")
+	pflag.Bool("lenient", false, "skips packets that fail to parse instead of discarding the round")
+	pflag.Bool("diagnostics", false, "prints parse diagnostics after reading")
+	pflag.Int("workers", 0, "number of rounds parsed concurrently (0=number of CPUs)")
+	pflag.String("layouts", "", "registers packet layouts from a JSON file (e.g. for a new season)")
+	pflag.String("rooms", "", "registers map floors and rooms from a JSON file")
+	pflag.String("layer", "positions", "heatmap points (positions, deaths, kills)")
+	pflag.String("role", "", "heatmap team role filter (attack, defense)")
+	pflag.StringSlice("players", nil, "heatmap username filter")
+	pflag.StringSlice("operators", nil, "heatmap operator filter")
+	pflag.Float64("from", 0, "heatmap start in seconds since the first clock tick of each round")
+	pflag.Float64("to", 0, "heatmap end in seconds since the first clock tick of each round (0=round end)")
+	pflag.Int("size", 1024, "heatmap image size in pixels along the longer side")
 	pflag.Parse()
 	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
 		log.Fatal().Err(err)
@@ -95,11 +133,26 @@ func setup() {
 		log.Info().Msg("https://github.com/redraskal/r6-dissect")
 		os.Exit(0)
 	}
-	extra := len(pflag.Args())
+	if path := viper.GetString("layouts"); path != "" {
+		if err := loadLayouts(path); err != nil {
+			log.Fatal().Err(err).Msg("could not load packet layouts")
+		}
+	}
+	if path := viper.GetString("rooms"); path != "" {
+		if err := loadRooms(path); err != nil {
+			log.Fatal().Err(err).Msg("could not load room geometry")
+		}
+	}
+	args := pflag.Args()
+	if len(args) > 0 && (args[0] == "anonymize" || args[0] == "rooms" || args[0] == "heatmap") {
+		viper.Set("command", args[0])
+		args = args[1:]
+	}
+	extra := len(args)
 	if extra < 1 && !piped(os.Stdin) {
 		log.Fatal().Msg("Specify a valid match replay file/folder path (*.rec files)")
 	} else if extra > 0 {
-		viper.Set("input", pflag.Args()[0])
+		viper.Set("input", args[0])
 	}
 	if !viper.IsSet("format") {
 		output := viper.GetString("output")
@@ -107,41 +160,57 @@ func setup() {
 			viper.Set("format", "excel")
 		} else if strings.HasSuffix(output, ".json") {
 			viper.Set("format", "json")
+		} else if strings.HasSuffix(output, ".html") {
+			viper.Set("format", "html")
 		}
 	}
 	format := strings.ToLower(viper.GetString("format"))
-	if len(format) > 0 && !(format == "json" || format == "excel") {
-		log.Fatal().Msg("Specify a valid output format (json, excel)")
+	if len(format) > 0 && !(format == "json" || format == "excel" || format == "html") {
+		log.Fatal().Msg("Specify a valid output format (json, excel, html)")
 	} else if len(format) == 0 {
 		viper.Set("format", "json")
 	}
 }
 
+func loadLayouts(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return dissect.LoadLayouts(f)
+}
+
+func loadRooms(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return dissect.LoadRooms(f)
+}
+
 func printHead(in *os.File) error {
 	stat, err := in.Stat()
 	if err != nil {
 		return err
 	}
 	if stat.IsDir() {
-		m, err := dissect.NewMatchReader(in)
+		paths, err := dissect.ListReplayFiles(in)
 		if err != nil {
 			return err
 		}
-		r, err := m.FirstRound()
-		if err != nil {
+		if in, err = os.Open(paths[0]); err != nil {
 			return err
 		}
-		r.Head()
-		return nil
+		defer in.Close()
 	}
-	r, err := dissect.NewReader(in)
+	// profile ids are only known once the player packets are read
+	h, _, err := dissect.ReadHeaderOnly(in, true)
 	if err != nil {
 		return err
 	}
-	if err := r.ReadPartial(); !dissect.Ok(err) {
-		return err
-	}
-	r.Head()
+	h.Print()
 	return nil
 }
 
@@ -150,45 +219,291 @@ func writeMatch(in *os.File, format OutputFormat, out io.Writer) error {
 	if err != nil {
 		return err
 	}
-	if err := m.Read(); !dissect.Ok(err) {
+	m.Stream = viper.GetBool("stream")
+	m.Lenient = viper.GetBool("lenient")
+	m.Workers = workers()
+	// the replay viewer plays back the movement
+	m.TrackMovement = viper.GetBool("movement") || format == HTML
+	m.MovementSampleRate = viper.GetInt("movement-sample")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := m.ReadContext(ctx); !dissect.Ok(err) {
 		return err
 	}
-	if format == Excel {
+	if viper.GetBool("diagnostics") {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		for i := 0; i < m.NumRounds(); i++ {
+			r, err := m.RoundAt(i)
+			if !dissect.Ok(err) {
+				return err
+			}
+			log.Info().Msgf("Round %d diagnostics:", i+1)
+			r.PrintDiagnostics()
+		}
+	}
+	switch format {
+	case Excel:
 		return m.WriteExcel(out)
+	case HTML:
+		return m.WriteHTML(out)
 	}
 	return m.WriteJSON(out)
 }
 
-func writeRound(in io.Reader, out io.Writer) error {
-	r, err := dissect.NewReader(in)
+func writeRound(in io.Reader, format OutputFormat, out io.Writer) error {
+	r, err := newReader(in)
 	if err != nil {
 		return err
 	}
-	// Enable movement tracking if flag is set
-	if viper.GetBool("movement") {
+	// Enable movement tracking if flag is set or for the replay viewer
+	if viper.GetBool("movement") || format == HTML {
 		sampleRate := viper.GetInt("movement-sample")
 		r.EnableMovementTracking(sampleRate)
 	}
 	type output struct {
 		dissect.Header
-		MatchFeedback []dissect.MatchUpdate      `json:"matchFeedback"`
-		PlayerStats   []dissect.PlayerRoundStats `json:"stats"`
-		Movements     []dissect.PlayerMovement   `json:"movements,omitempty"`
-		AmmoUpdates   []dissect.AmmoUpdate       `json:"ammoUpdates,omitempty"`
+		MatchFeedback  []dissect.MatchUpdate      `json:"matchFeedback"`
+		PlayerStats    []dissect.PlayerRoundStats `json:"stats"`
+		Movements      []dissect.PlayerMovement   `json:"movements,omitempty"`
+		Entities       []dissect.EntityMovement   `json:"entities,omitempty"`
+		AmmoUpdates    []dissect.AmmoUpdate       `json:"ammoUpdates,omitempty"`
+		AbilityUpdates []dissect.AbilityUpdate    `json:"abilityUpdates,omitempty"`
+		Rooms          []dissect.PlayerRooms      `json:"rooms,omitempty"`
 	}
-	if err := r.Read(); !dissect.Ok(err) {
+	r.Lenient = viper.GetBool("lenient")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := r.ReadContext(ctx); !dissect.Ok(err) {
 		return err
 	}
+	if viper.GetBool("diagnostics") {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		r.PrintDiagnostics()
+	}
+	if format == HTML {
+		return r.WriteHTML(out)
+	}
 	encoder := json.NewEncoder(out)
 	return encoder.Encode(output{
 		r.Header,
 		r.MatchFeedback,
 		r.PlayerStats(),
 		r.GetMovementData(),
+		r.GetEntityMovements(),
 		r.AmmoUpdates,
+		r.AbilityUpdates,
+		r.RoomTimeline(),
 	})
 }
 
+// anonymize writes the replay or match folder in with pseudonyms
+// to the output file or folder.
+func anonymize(in *os.File) error {
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	a := dissect.NewAnonymizer()
+	if !stat.IsDir() {
+		out, err := viperFileOrDefault("output", os.Stdout, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		return a.Anonymize(in, out)
+	}
+	dir := viper.GetString("output")
+	if dir == "" {
+		return errors.New("anonymize requires an output folder for a match folder input")
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	paths, err := dissect.ListReplayFiles(in)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := anonymizeFile(a, p, filepath.Join(dir, filepath.Base(p))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func anonymizeFile(a *dissect.Anonymizer, src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return a.Anonymize(in, out)
+}
+
+// validateRooms writes a report of the positions outside every room
+// of the map for each round of in.
+func validateRooms(in *os.File, out io.Writer) error {
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	rounds := make([]*dissect.Reader, 0)
+	if stat.IsDir() {
+		m, err := dissect.NewMatchReader(in)
+		if err != nil {
+			return err
+		}
+		m.Stream = viper.GetBool("stream")
+		m.Lenient = viper.GetBool("lenient")
+		m.Workers = workers()
+		m.TrackMovement = true
+		m.MovementSampleRate = viper.GetInt("movement-sample")
+		if err := m.Read(); !dissect.Ok(err) {
+			return err
+		}
+		for i := 0; i < m.NumRounds(); i++ {
+			r, err := m.RoundAt(i)
+			if !dissect.Ok(err) {
+				return err
+			}
+			rounds = append(rounds, r)
+		}
+	} else {
+		r, err := newReader(in)
+		if err != nil {
+			return err
+		}
+		r.EnableMovementTracking(viper.GetInt("movement-sample"))
+		r.Lenient = viper.GetBool("lenient")
+		if err := r.Read(); !dissect.Ok(err) {
+			return err
+		}
+		rounds = append(rounds, r)
+	}
+	reports := make([]dissect.RoomReport, 0, len(rounds))
+	for _, r := range rounds {
+		report, err := r.ValidateRooms()
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+	return json.NewEncoder(out).Encode(reports)
+}
+
+// writeHeatmaps writes a PNG heatmap per map floor of a replay, a match
+// folder or a folder of match folders to the output folder.
+func writeHeatmaps(in *os.File) error {
+	dir := viper.GetString("output")
+	if dir == "" {
+		return errors.New("heatmap requires an output folder")
+	}
+	layer := dissect.HeatmapLayer(strings.ToLower(viper.GetString("layer")))
+	if layer != dissect.HeatPositions && layer != dissect.HeatDeaths && layer != dissect.HeatKills {
+		return fmt.Errorf("invalid heatmap layer %q (positions, deaths, kills)", layer)
+	}
+	filter := dissect.HeatmapFilter{
+		Players:   viper.GetStringSlice("players"),
+		Operators: viper.GetStringSlice("operators"),
+		From:      viper.GetFloat64("from"),
+		To:        viper.GetFloat64("to"),
+	}
+	switch role := strings.ToLower(viper.GetString("role")); role {
+	case "":
+	case "attack":
+		filter.Role = dissect.Attack
+	case "defense":
+		filter.Role = dissect.Defense
+	default:
+		return fmt.Errorf("invalid heatmap role %q (attack, defense)", role)
+	}
+	h := dissect.NewHeatmap(layer, filter)
+	if err := addHeatmap(h, in); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	for _, k := range h.Keys() {
+		name := fmt.Sprintf("%s-%s-%s.png", k.Map, k.Floor, layer)
+		if err := writeHeatmap(h, k, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addHeatmap adds the rounds of in to h. Folders without replays
+// are read as a folder of match folders.
+func addHeatmap(h *dissect.Heatmap, in *os.File) error {
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		r, err := newReader(in)
+		if err != nil {
+			return err
+		}
+		r.EnableMovementTracking(viper.GetInt("movement-sample"))
+		r.Lenient = viper.GetBool("lenient")
+		if err := r.Read(); !dissect.Ok(err) {
+			return err
+		}
+		h.AddRound(r)
+		return nil
+	}
+	m, err := dissect.NewMatchReader(in)
+	if errors.Is(err, dissect.ErrInvalidFolder) {
+		entries, err := os.ReadDir(in.Name())
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			match, err := os.Open(filepath.Join(in.Name(), entry.Name()))
+			if err != nil {
+				return err
+			}
+			err = addHeatmap(h, match)
+			match.Close()
+			if err != nil && !errors.Is(err, dissect.ErrInvalidFolder) {
+				return err
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.Stream = viper.GetBool("stream")
+	m.Lenient = viper.GetBool("lenient")
+	m.Workers = workers()
+	m.TrackMovement = true
+	m.MovementSampleRate = viper.GetInt("movement-sample")
+	if err := m.Read(); !dissect.Ok(err) {
+		return err
+	}
+	h.AddMatch(m)
+	return nil
+}
+
+func writeHeatmap(h *dissect.Heatmap, k dissect.HeatmapKey, path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return h.WritePNG(out, k, viper.GetInt("size"))
+}
+
 func writeRoundDump(in io.Reader, out *os.File) error {
 	r, err := dissect.NewReader(in)
 	if err != nil {
@@ -198,6 +513,21 @@ func writeRoundDump(in io.Reader, out *os.File) error {
 	return err
 }
 
+// workers returns the number of rounds to parse concurrently, 0 meaning one per CPU.
+func workers() int {
+	if n := viper.GetInt("workers"); n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+func newReader(in io.Reader) (*dissect.Reader, error) {
+	if viper.GetBool("stream") {
+		return dissect.NewStreamReader(in)
+	}
+	return dissect.NewReader(in)
+}
+
 func piped(f *os.File) bool {
 	stat, err := f.Stat()
 	if err != nil {